@@ -0,0 +1,8 @@
+-- the best laps of every game are stored in one table, the laps stored before are the Forza Motorsport laps.
+-- CarPerformanceIndex is sent by Forza only, so the laps are unique by the car class instead.
+-- DiRT Rally 2.0 does not send the car and the stage IDs: its stage times are stored with the car 0 and the stage
+-- length as TrackOrdinal, so the times of every car are compared together and the stages of the same length collide.
+ALTER TABLE tmd_forzamotorsport2023_bestlaps
+    ADD COLUMN game varchar(20) NOT NULL DEFAULT 'fms2023' AFTER user_id,
+    DROP INDEX tmd_forzamotorsport2023_bestlaps_UN,
+    ADD CONSTRAINT tmd_forzamotorsport2023_bestlaps_UN UNIQUE KEY (game,CarOrdinal,CarClass,BestLap,TrackOrdinal,user_id);
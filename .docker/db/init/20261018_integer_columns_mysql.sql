@@ -0,0 +1,24 @@
+-- the integer fields are stored in their native types, eg. TimestampMS does not fit the float precision
+ALTER TABLE `tmd_forzamotorsport2023`
+    MODIFY `IsRaceOn` int(11) DEFAULT NULL,
+    MODIFY `TimestampMS` int(10) unsigned DEFAULT NULL,
+    MODIFY `WheelOnRumbleStripFrontLeft` int(11) DEFAULT NULL,
+    MODIFY `WheelOnRumbleStripFrontRight` int(11) DEFAULT NULL,
+    MODIFY `WheelOnRumbleStripRearLeft` int(11) DEFAULT NULL,
+    MODIFY `WheelOnRumbleStripRearRight` int(11) DEFAULT NULL,
+    MODIFY `CarOrdinal` int(11) DEFAULT NULL,
+    MODIFY `CarClass` int(11) DEFAULT NULL,
+    MODIFY `CarPerformanceIndex` int(11) DEFAULT NULL,
+    MODIFY `DrivetrainType` int(11) DEFAULT NULL,
+    MODIFY `NumCylinders` int(11) DEFAULT NULL,
+    MODIFY `LapNumber` smallint(5) unsigned DEFAULT NULL,
+    MODIFY `RacePosition` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `Accel` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `Brake` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `Clutch` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `HandBrake` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `Gear` tinyint(3) unsigned DEFAULT NULL,
+    MODIFY `Steer` tinyint(4) DEFAULT NULL,
+    MODIFY `NormalizedDrivingLine` tinyint(4) DEFAULT NULL,
+    MODIFY `NormalizedAIBrakeDifference` tinyint(4) DEFAULT NULL,
+    MODIFY `TrackOrdinal` int(11) DEFAULT NULL;
@@ -0,0 +1,94 @@
+CREATE TABLE IF NOT EXISTS `tmd_assettocorsa` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `Size` int(11) DEFAULT NULL,
+    `SpeedKmh` float DEFAULT NULL,
+    `SpeedMph` float DEFAULT NULL,
+    `SpeedMs` float DEFAULT NULL,
+    `IsAbsEnabled` tinyint(3) unsigned DEFAULT NULL,
+    `IsAbsInAction` tinyint(3) unsigned DEFAULT NULL,
+    `IsTcInAction` tinyint(3) unsigned DEFAULT NULL,
+    `IsTcEnabled` tinyint(3) unsigned DEFAULT NULL,
+    `IsInPit` tinyint(3) unsigned DEFAULT NULL,
+    `IsEngineLimiterOn` tinyint(3) unsigned DEFAULT NULL,
+    `AccGVertical` float DEFAULT NULL,
+    `AccGHorizontal` float DEFAULT NULL,
+    `AccGFrontal` float DEFAULT NULL,
+    `LapTime` int(11) DEFAULT NULL,
+    `LastLap` int(11) DEFAULT NULL,
+    `BestLap` int(11) DEFAULT NULL,
+    `LapCount` int(11) DEFAULT NULL,
+    `Gas` float DEFAULT NULL,
+    `Brake` float DEFAULT NULL,
+    `Clutch` float DEFAULT NULL,
+    `EngineRPM` float DEFAULT NULL,
+    `Steer` float DEFAULT NULL,
+    `Gear` int(11) DEFAULT NULL,
+    `CgHeight` float DEFAULT NULL,
+    `WheelAngularSpeedFrontLeft` float DEFAULT NULL,
+    `WheelAngularSpeedFrontRight` float DEFAULT NULL,
+    `WheelAngularSpeedRearLeft` float DEFAULT NULL,
+    `WheelAngularSpeedRearRight` float DEFAULT NULL,
+    `SlipAngleFrontLeft` float DEFAULT NULL,
+    `SlipAngleFrontRight` float DEFAULT NULL,
+    `SlipAngleRearLeft` float DEFAULT NULL,
+    `SlipAngleRearRight` float DEFAULT NULL,
+    `SlipAngleContactPatchFrontLeft` float DEFAULT NULL,
+    `SlipAngleContactPatchFrontRight` float DEFAULT NULL,
+    `SlipAngleContactPatchRearLeft` float DEFAULT NULL,
+    `SlipAngleContactPatchRearRight` float DEFAULT NULL,
+    `SlipRatioFrontLeft` float DEFAULT NULL,
+    `SlipRatioFrontRight` float DEFAULT NULL,
+    `SlipRatioRearLeft` float DEFAULT NULL,
+    `SlipRatioRearRight` float DEFAULT NULL,
+    `TyreSlipFrontLeft` float DEFAULT NULL,
+    `TyreSlipFrontRight` float DEFAULT NULL,
+    `TyreSlipRearLeft` float DEFAULT NULL,
+    `TyreSlipRearRight` float DEFAULT NULL,
+    `NdSlipFrontLeft` float DEFAULT NULL,
+    `NdSlipFrontRight` float DEFAULT NULL,
+    `NdSlipRearLeft` float DEFAULT NULL,
+    `NdSlipRearRight` float DEFAULT NULL,
+    `LoadFrontLeft` float DEFAULT NULL,
+    `LoadFrontRight` float DEFAULT NULL,
+    `LoadRearLeft` float DEFAULT NULL,
+    `LoadRearRight` float DEFAULT NULL,
+    `DyFrontLeft` float DEFAULT NULL,
+    `DyFrontRight` float DEFAULT NULL,
+    `DyRearLeft` float DEFAULT NULL,
+    `DyRearRight` float DEFAULT NULL,
+    `MzFrontLeft` float DEFAULT NULL,
+    `MzFrontRight` float DEFAULT NULL,
+    `MzRearLeft` float DEFAULT NULL,
+    `MzRearRight` float DEFAULT NULL,
+    `TyreDirtyLevelFrontLeft` float DEFAULT NULL,
+    `TyreDirtyLevelFrontRight` float DEFAULT NULL,
+    `TyreDirtyLevelRearLeft` float DEFAULT NULL,
+    `TyreDirtyLevelRearRight` float DEFAULT NULL,
+    `CamberRADFrontLeft` float DEFAULT NULL,
+    `CamberRADFrontRight` float DEFAULT NULL,
+    `CamberRADRearLeft` float DEFAULT NULL,
+    `CamberRADRearRight` float DEFAULT NULL,
+    `TyreRadiusFrontLeft` float DEFAULT NULL,
+    `TyreRadiusFrontRight` float DEFAULT NULL,
+    `TyreRadiusRearLeft` float DEFAULT NULL,
+    `TyreRadiusRearRight` float DEFAULT NULL,
+    `TyreLoadedRadiusFrontLeft` float DEFAULT NULL,
+    `TyreLoadedRadiusFrontRight` float DEFAULT NULL,
+    `TyreLoadedRadiusRearLeft` float DEFAULT NULL,
+    `TyreLoadedRadiusRearRight` float DEFAULT NULL,
+    `SuspensionHeightFrontLeft` float DEFAULT NULL,
+    `SuspensionHeightFrontRight` float DEFAULT NULL,
+    `SuspensionHeightRearLeft` float DEFAULT NULL,
+    `SuspensionHeightRearRight` float DEFAULT NULL,
+    `CarPositionNormalized` float DEFAULT NULL,
+    `CarSlope` float DEFAULT NULL,
+    `CarCoordinatesX` float DEFAULT NULL,
+    `CarCoordinatesY` float DEFAULT NULL,
+    `CarCoordinatesZ` float DEFAULT NULL,
+    `CarIdentifierNumber` float DEFAULT NULL,
+    `Lap` float DEFAULT NULL,
+    `Time` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS `tmd_assettocorsacompetizione` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `SessionType` float DEFAULT NULL,
+    `SessionPhase` float DEFAULT NULL,
+    `SessionTime` float DEFAULT NULL,
+    `SessionEndTime` float DEFAULT NULL,
+    `AmbientTemp` float DEFAULT NULL,
+    `TrackTemp` float DEFAULT NULL,
+    `FocusedCarIndex` float DEFAULT NULL,
+    `CarIndex` float DEFAULT NULL,
+    `RaceNumber` float DEFAULT NULL,
+    `CarModelType` float DEFAULT NULL,
+    `CupCategory` float DEFAULT NULL,
+    `DriverIndex` float DEFAULT NULL,
+    `Gear` float DEFAULT NULL,
+    `WorldPosX` float DEFAULT NULL,
+    `WorldPosY` float DEFAULT NULL,
+    `Yaw` float DEFAULT NULL,
+    `CarLocation` float DEFAULT NULL,
+    `Kmh` float DEFAULT NULL,
+    `Position` float DEFAULT NULL,
+    `CupPosition` float DEFAULT NULL,
+    `TrackPosition` float DEFAULT NULL,
+    `SplinePosition` float DEFAULT NULL,
+    `Laps` float DEFAULT NULL,
+    `Delta` float DEFAULT NULL,
+    `BestSessionLap` float DEFAULT NULL,
+    `LastLap` float DEFAULT NULL,
+    `LastLapSplit1` float DEFAULT NULL,
+    `LastLapSplit2` float DEFAULT NULL,
+    `LastLapSplit3` float DEFAULT NULL,
+    `LastLapIsInvalid` float DEFAULT NULL,
+    `CurrentLap` float DEFAULT NULL,
+    `CurrentLapIsInvalid` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS `tmd_beamng` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `Time` float DEFAULT NULL,
+    `Flags` float DEFAULT NULL,
+    `Gear` float DEFAULT NULL,
+    `PlayerID` float DEFAULT NULL,
+    `Speed` float DEFAULT NULL,
+    `RPM` float DEFAULT NULL,
+    `Turbo` float DEFAULT NULL,
+    `EngineTemperature` float DEFAULT NULL,
+    `Fuel` float DEFAULT NULL,
+    `OilPressure` float DEFAULT NULL,
+    `OilTemperature` float DEFAULT NULL,
+    `DashLights` float DEFAULT NULL,
+    `ShowLights` float DEFAULT NULL,
+    `Throttle` float DEFAULT NULL,
+    `Brake` float DEFAULT NULL,
+    `Clutch` float DEFAULT NULL,
+    `OutSimTime` float DEFAULT NULL,
+    `AngularVelocityX` float DEFAULT NULL,
+    `AngularVelocityY` float DEFAULT NULL,
+    `AngularVelocityZ` float DEFAULT NULL,
+    `Heading` float DEFAULT NULL,
+    `Pitch` float DEFAULT NULL,
+    `Roll` float DEFAULT NULL,
+    `AccelerationX` float DEFAULT NULL,
+    `AccelerationY` float DEFAULT NULL,
+    `AccelerationZ` float DEFAULT NULL,
+    `VelocityX` float DEFAULT NULL,
+    `VelocityY` float DEFAULT NULL,
+    `VelocityZ` float DEFAULT NULL,
+    `PositionX` float DEFAULT NULL,
+    `PositionY` float DEFAULT NULL,
+    `PositionZ` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,80 @@
+CREATE TABLE IF NOT EXISTS `tmd_dirtrally2` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `StageTime` float DEFAULT NULL,
+    `StageDistance` float DEFAULT NULL,
+    `StageLength` float DEFAULT NULL,
+    `StageProgress` float DEFAULT NULL,
+    `Split1Time` float DEFAULT NULL,
+    `Split2Time` float DEFAULT NULL,
+    `LastLap` float DEFAULT NULL,
+    `LapNumber` float DEFAULT NULL,
+    `TrackOrdinal` float DEFAULT NULL,
+    `CarOrdinal` float DEFAULT NULL,
+    `CarClass` float DEFAULT NULL,
+    `RunTime` float DEFAULT NULL,
+    `TotalDistance` float DEFAULT NULL,
+    `PositionX` float DEFAULT NULL,
+    `PositionY` float DEFAULT NULL,
+    `PositionZ` float DEFAULT NULL,
+    `Speed` float DEFAULT NULL,
+    `VelocityX` float DEFAULT NULL,
+    `VelocityY` float DEFAULT NULL,
+    `VelocityZ` float DEFAULT NULL,
+    `RollX` float DEFAULT NULL,
+    `RollY` float DEFAULT NULL,
+    `RollZ` float DEFAULT NULL,
+    `PitchX` float DEFAULT NULL,
+    `PitchY` float DEFAULT NULL,
+    `PitchZ` float DEFAULT NULL,
+    `SuspensionPositionRearLeft` float DEFAULT NULL,
+    `SuspensionPositionRearRight` float DEFAULT NULL,
+    `SuspensionPositionFrontLeft` float DEFAULT NULL,
+    `SuspensionPositionFrontRight` float DEFAULT NULL,
+    `SuspensionVelocityRearLeft` float DEFAULT NULL,
+    `SuspensionVelocityRearRight` float DEFAULT NULL,
+    `SuspensionVelocityFrontLeft` float DEFAULT NULL,
+    `SuspensionVelocityFrontRight` float DEFAULT NULL,
+    `WheelSpeedRearLeft` float DEFAULT NULL,
+    `WheelSpeedRearRight` float DEFAULT NULL,
+    `WheelSpeedFrontLeft` float DEFAULT NULL,
+    `WheelSpeedFrontRight` float DEFAULT NULL,
+    `Throttle` float DEFAULT NULL,
+    `Steering` float DEFAULT NULL,
+    `Brake` float DEFAULT NULL,
+    `Clutch` float DEFAULT NULL,
+    `Gear` float DEFAULT NULL,
+    `GForceLateral` float DEFAULT NULL,
+    `GForceLongitudinal` float DEFAULT NULL,
+    `CurrentLap` float DEFAULT NULL,
+    `EngineRate` float DEFAULT NULL,
+    `SliProSupport` float DEFAULT NULL,
+    `RacePosition` float DEFAULT NULL,
+    `KersLevel` float DEFAULT NULL,
+    `KersMaxLevel` float DEFAULT NULL,
+    `Drs` float DEFAULT NULL,
+    `TractionControl` float DEFAULT NULL,
+    `AntiLockBrakes` float DEFAULT NULL,
+    `FuelInTank` float DEFAULT NULL,
+    `FuelCapacity` float DEFAULT NULL,
+    `InPit` float DEFAULT NULL,
+    `Sector` float DEFAULT NULL,
+    `Sector1Time` float DEFAULT NULL,
+    `Sector2Time` float DEFAULT NULL,
+    `BrakeTemperatureRearLeft` float DEFAULT NULL,
+    `BrakeTemperatureRearRight` float DEFAULT NULL,
+    `BrakeTemperatureFrontLeft` float DEFAULT NULL,
+    `BrakeTemperatureFrontRight` float DEFAULT NULL,
+    `TyrePressureRearLeft` float DEFAULT NULL,
+    `TyrePressureRearRight` float DEFAULT NULL,
+    `TyrePressureFrontLeft` float DEFAULT NULL,
+    `TyrePressureFrontRight` float DEFAULT NULL,
+    `CompletedLaps` float DEFAULT NULL,
+    `TotalLaps` float DEFAULT NULL,
+    `LastLapTime` float DEFAULT NULL,
+    `MaxEngineRate` float DEFAULT NULL,
+    `IdleEngineRate` float DEFAULT NULL,
+    `MaxGears` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
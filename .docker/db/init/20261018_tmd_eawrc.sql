@@ -0,0 +1,61 @@
+CREATE TABLE IF NOT EXISTS `tmd_eawrc` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `StageTime` float DEFAULT NULL,
+    `StageDistance` float DEFAULT NULL,
+    `StageLength` float DEFAULT NULL,
+    `StageProgress` float DEFAULT NULL,
+    `Split1Time` float DEFAULT NULL,
+    `Split2Time` float DEFAULT NULL,
+    `LastLap` float DEFAULT NULL,
+    `LapNumber` float DEFAULT NULL,
+    `TrackOrdinal` float DEFAULT NULL,
+    `CarOrdinal` float DEFAULT NULL,
+    `CarClass` float DEFAULT NULL,
+    `GameTotalTime` float DEFAULT NULL,
+    `GameDeltaTime` float DEFAULT NULL,
+    `ShiftlightsFraction` float DEFAULT NULL,
+    `ShiftlightsRpmStart` float DEFAULT NULL,
+    `ShiftlightsRpmEnd` float DEFAULT NULL,
+    `ShiftlightsRpmValid` tinyint(3) unsigned DEFAULT NULL,
+    `VehicleGearIndex` tinyint(3) unsigned DEFAULT NULL,
+    `VehicleGearIndexNeutral` tinyint(3) unsigned DEFAULT NULL,
+    `VehicleGearIndexReverse` tinyint(3) unsigned DEFAULT NULL,
+    `VehicleGearMaximum` tinyint(3) unsigned DEFAULT NULL,
+    `VehicleSpeed` float DEFAULT NULL,
+    `VehicleTransmissionSpeed` float DEFAULT NULL,
+    `VehiclePositionX` float DEFAULT NULL,
+    `VehiclePositionY` float DEFAULT NULL,
+    `VehiclePositionZ` float DEFAULT NULL,
+    `VehicleVelocityX` float DEFAULT NULL,
+    `VehicleVelocityY` float DEFAULT NULL,
+    `VehicleVelocityZ` float DEFAULT NULL,
+    `VehicleAccelerationX` float DEFAULT NULL,
+    `VehicleAccelerationY` float DEFAULT NULL,
+    `VehicleAccelerationZ` float DEFAULT NULL,
+    `VehicleBrakeTemperatureBl` float DEFAULT NULL,
+    `VehicleBrakeTemperatureBr` float DEFAULT NULL,
+    `VehicleBrakeTemperatureFl` float DEFAULT NULL,
+    `VehicleBrakeTemperatureFr` float DEFAULT NULL,
+    `VehicleEngineRpmMax` float DEFAULT NULL,
+    `VehicleEngineRpmIdle` float DEFAULT NULL,
+    `VehicleEngineRpmCurrent` float DEFAULT NULL,
+    `VehicleThrottle` float DEFAULT NULL,
+    `VehicleBrake` float DEFAULT NULL,
+    `VehicleClutch` float DEFAULT NULL,
+    `VehicleSteering` float DEFAULT NULL,
+    `VehicleHandbrake` float DEFAULT NULL,
+    `VehicleId` smallint(5) unsigned DEFAULT NULL,
+    `VehicleClassId` smallint(5) unsigned DEFAULT NULL,
+    `VehicleManufacturerId` smallint(5) unsigned DEFAULT NULL,
+    `LocationId` smallint(5) unsigned DEFAULT NULL,
+    `RouteId` smallint(5) unsigned DEFAULT NULL,
+    `StageCurrentTime` float DEFAULT NULL,
+    `StageCurrentDistance` float DEFAULT NULL,
+    `StagePreviousSplitTime` float DEFAULT NULL,
+    `StageResultTime` float DEFAULT NULL,
+    `StageResultTimePenalty` float DEFAULT NULL,
+    `StageResultStatus` tinyint(3) unsigned DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,131 @@
+CREATE TABLE IF NOT EXISTS `tmd_f1` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `PacketFormat` float DEFAULT NULL,
+    `SessionTime` float DEFAULT NULL,
+    `FrameIdentifier` float DEFAULT NULL,
+    `PlayerCarIndex` float DEFAULT NULL,
+    `Weather` float DEFAULT NULL,
+    `TrackTemperature` float DEFAULT NULL,
+    `AirTemperature` float DEFAULT NULL,
+    `TotalLaps` float DEFAULT NULL,
+    `TrackLength` float DEFAULT NULL,
+    `SessionType` float DEFAULT NULL,
+    `TrackId` float DEFAULT NULL,
+    `Formula` float DEFAULT NULL,
+    `SessionTimeLeft` float DEFAULT NULL,
+    `SessionDuration` float DEFAULT NULL,
+    `PitSpeedLimit` float DEFAULT NULL,
+    `GamePaused` float DEFAULT NULL,
+    `IsSpectating` float DEFAULT NULL,
+    `SpectatorCarIndex` float DEFAULT NULL,
+    `WorldPositionX` float DEFAULT NULL,
+    `WorldPositionY` float DEFAULT NULL,
+    `WorldPositionZ` float DEFAULT NULL,
+    `WorldVelocityX` float DEFAULT NULL,
+    `WorldVelocityY` float DEFAULT NULL,
+    `WorldVelocityZ` float DEFAULT NULL,
+    `WorldForwardDirX` float DEFAULT NULL,
+    `WorldForwardDirY` float DEFAULT NULL,
+    `WorldForwardDirZ` float DEFAULT NULL,
+    `WorldRightDirX` float DEFAULT NULL,
+    `WorldRightDirY` float DEFAULT NULL,
+    `WorldRightDirZ` float DEFAULT NULL,
+    `GForceLateral` float DEFAULT NULL,
+    `GForceLongitudinal` float DEFAULT NULL,
+    `GForceVertical` float DEFAULT NULL,
+    `Yaw` float DEFAULT NULL,
+    `Pitch` float DEFAULT NULL,
+    `Roll` float DEFAULT NULL,
+    `LastLapTimeInMS` float DEFAULT NULL,
+    `CurrentLapTimeInMS` float DEFAULT NULL,
+    `Sector1TimeInMS` float DEFAULT NULL,
+    `Sector1TimeMinutes` float DEFAULT NULL,
+    `Sector2TimeInMS` float DEFAULT NULL,
+    `Sector2TimeMinutes` float DEFAULT NULL,
+    `DeltaToCarInFrontInMS` float DEFAULT NULL,
+    `DeltaToCarInFrontMinutes` float DEFAULT NULL,
+    `DeltaToRaceLeaderInMS` float DEFAULT NULL,
+    `DeltaToRaceLeaderMinutes` float DEFAULT NULL,
+    `LapDistance` float DEFAULT NULL,
+    `TotalDistance` float DEFAULT NULL,
+    `SafetyCarDelta` float DEFAULT NULL,
+    `CarPosition` float DEFAULT NULL,
+    `CurrentLapNum` float DEFAULT NULL,
+    `PitStatus` float DEFAULT NULL,
+    `NumPitStops` float DEFAULT NULL,
+    `Sector` float DEFAULT NULL,
+    `CurrentLapInvalid` float DEFAULT NULL,
+    `Penalties` float DEFAULT NULL,
+    `TotalWarnings` float DEFAULT NULL,
+    `CornerCuttingWarnings` float DEFAULT NULL,
+    `NumUnservedDriveThroughPens` float DEFAULT NULL,
+    `NumUnservedStopGoPens` float DEFAULT NULL,
+    `GridPosition` float DEFAULT NULL,
+    `DriverStatus` float DEFAULT NULL,
+    `ResultStatus` float DEFAULT NULL,
+    `PitLaneTimerActive` float DEFAULT NULL,
+    `PitLaneTimeInLaneInMS` float DEFAULT NULL,
+    `PitStopTimerInMS` float DEFAULT NULL,
+    `PitStopShouldServePen` float DEFAULT NULL,
+    `SpeedTrapFastestSpeed` float DEFAULT NULL,
+    `SpeedTrapFastestLap` float DEFAULT NULL,
+    `Speed` float DEFAULT NULL,
+    `Throttle` float DEFAULT NULL,
+    `Steer` float DEFAULT NULL,
+    `Brake` float DEFAULT NULL,
+    `Clutch` float DEFAULT NULL,
+    `Gear` float DEFAULT NULL,
+    `EngineRPM` float DEFAULT NULL,
+    `DRS` float DEFAULT NULL,
+    `RevLightsPercent` float DEFAULT NULL,
+    `RevLightsBitValue` float DEFAULT NULL,
+    `BrakesTemperatureRearLeft` float DEFAULT NULL,
+    `BrakesTemperatureRearRight` float DEFAULT NULL,
+    `BrakesTemperatureFrontLeft` float DEFAULT NULL,
+    `BrakesTemperatureFrontRight` float DEFAULT NULL,
+    `TyresSurfaceTemperatureRearLeft` float DEFAULT NULL,
+    `TyresSurfaceTemperatureRearRight` float DEFAULT NULL,
+    `TyresSurfaceTemperatureFrontLeft` float DEFAULT NULL,
+    `TyresSurfaceTemperatureFrontRight` float DEFAULT NULL,
+    `TyresInnerTemperatureRearLeft` float DEFAULT NULL,
+    `TyresInnerTemperatureRearRight` float DEFAULT NULL,
+    `TyresInnerTemperatureFrontLeft` float DEFAULT NULL,
+    `TyresInnerTemperatureFrontRight` float DEFAULT NULL,
+    `EngineTemperature` float DEFAULT NULL,
+    `TyresPressureRearLeft` float DEFAULT NULL,
+    `TyresPressureRearRight` float DEFAULT NULL,
+    `TyresPressureFrontLeft` float DEFAULT NULL,
+    `TyresPressureFrontRight` float DEFAULT NULL,
+    `SurfaceTypeRearLeft` float DEFAULT NULL,
+    `SurfaceTypeRearRight` float DEFAULT NULL,
+    `SurfaceTypeFrontLeft` float DEFAULT NULL,
+    `SurfaceTypeFrontRight` float DEFAULT NULL,
+    `TractionControl` float DEFAULT NULL,
+    `AntiLockBrakes` float DEFAULT NULL,
+    `FuelMix` float DEFAULT NULL,
+    `FrontBrakeBias` float DEFAULT NULL,
+    `PitLimiterStatus` float DEFAULT NULL,
+    `FuelInTank` float DEFAULT NULL,
+    `FuelCapacity` float DEFAULT NULL,
+    `FuelRemainingLaps` float DEFAULT NULL,
+    `MaxRPM` float DEFAULT NULL,
+    `IdleRPM` float DEFAULT NULL,
+    `MaxGears` float DEFAULT NULL,
+    `DRSAllowed` float DEFAULT NULL,
+    `DRSActivationDistance` float DEFAULT NULL,
+    `ActualTyreCompound` float DEFAULT NULL,
+    `VisualTyreCompound` float DEFAULT NULL,
+    `TyresAgeLaps` float DEFAULT NULL,
+    `VehicleFiaFlags` float DEFAULT NULL,
+    `EnginePowerICE` float DEFAULT NULL,
+    `EnginePowerMGUK` float DEFAULT NULL,
+    `ERSStoreEnergy` float DEFAULT NULL,
+    `ERSDeployMode` float DEFAULT NULL,
+    `ERSHarvestedThisLapMGUK` float DEFAULT NULL,
+    `ERSHarvestedThisLapMGUH` float DEFAULT NULL,
+    `ERSDeployedThisLap` float DEFAULT NULL,
+    `NetworkPaused` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,90 @@
+CREATE TABLE IF NOT EXISTS `tmd_forzahorizon` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` int(11) DEFAULT NULL,
+    `TimestampMS` int(10) unsigned DEFAULT NULL,
+    `EngineMaxRpm` float DEFAULT NULL,
+    `EngineIdleRpm` float DEFAULT NULL,
+    `CurrentEngineRpm` float DEFAULT NULL,
+    `AccelerationX` float DEFAULT NULL,
+    `AccelerationY` float DEFAULT NULL,
+    `AccelerationZ` float DEFAULT NULL,
+    `VelocityX` float DEFAULT NULL,
+    `VelocityY` float DEFAULT NULL,
+    `VelocityZ` float DEFAULT NULL,
+    `AngularVelocityX` float DEFAULT NULL,
+    `AngularVelocityY` float DEFAULT NULL,
+    `AngularVelocityZ` float DEFAULT NULL,
+    `Yaw` float DEFAULT NULL,
+    `Pitch` float DEFAULT NULL,
+    `Roll` float DEFAULT NULL,
+    `NormalizedSuspensionTravelFrontLeft` float DEFAULT NULL,
+    `NormalizedSuspensionTravelFrontRight` float DEFAULT NULL,
+    `NormalizedSuspensionTravelRearLeft` float DEFAULT NULL,
+    `NormalizedSuspensionTravelRearRight` float DEFAULT NULL,
+    `TireSlipRatioFrontLeft` float DEFAULT NULL,
+    `TireSlipRatioFrontRight` float DEFAULT NULL,
+    `TireSlipRatioRearLeft` float DEFAULT NULL,
+    `TireSlipRatioRearRight` float DEFAULT NULL,
+    `WheelRotationSpeedFrontLeft` float DEFAULT NULL,
+    `WheelRotationSpeedFrontRight` float DEFAULT NULL,
+    `WheelRotationSpeedRearLeft` float DEFAULT NULL,
+    `WheelRotationSpeedRearRight` float DEFAULT NULL,
+    `WheelOnRumbleStripFrontLeft` int(11) DEFAULT NULL,
+    `WheelOnRumbleStripFrontRight` int(11) DEFAULT NULL,
+    `WheelOnRumbleStripRearLeft` int(11) DEFAULT NULL,
+    `WheelOnRumbleStripRearRight` int(11) DEFAULT NULL,
+    `WheelInPuddleDepthFrontLeft` float DEFAULT NULL,
+    `WheelInPuddleDepthFrontRight` float DEFAULT NULL,
+    `WheelInPuddleDepthRearLeft` float DEFAULT NULL,
+    `WheelInPuddleDepthRearRight` float DEFAULT NULL,
+    `SurfaceRumbleFrontLeft` float DEFAULT NULL,
+    `SurfaceRumbleFrontRight` float DEFAULT NULL,
+    `SurfaceRumbleRearLeft` float DEFAULT NULL,
+    `SurfaceRumbleRearRight` float DEFAULT NULL,
+    `TireSlipAngleFrontLeft` float DEFAULT NULL,
+    `TireSlipAngleFrontRight` float DEFAULT NULL,
+    `TireSlipAngleRearLeft` float DEFAULT NULL,
+    `TireSlipAngleRearRight` float DEFAULT NULL,
+    `TireCombinedSlipFrontLeft` float DEFAULT NULL,
+    `TireCombinedSlipFrontRight` float DEFAULT NULL,
+    `TireCombinedSlipRearLeft` float DEFAULT NULL,
+    `TireCombinedSlipRearRight` float DEFAULT NULL,
+    `SuspensionTravelMetersFrontLeft` float DEFAULT NULL,
+    `SuspensionTravelMetersFrontRight` float DEFAULT NULL,
+    `SuspensionTravelMetersRearLeft` float DEFAULT NULL,
+    `SuspensionTravelMetersRearRight` float DEFAULT NULL,
+    `CarOrdinal` int(11) DEFAULT NULL,
+    `CarClass` int(11) DEFAULT NULL,
+    `CarPerformanceIndex` int(11) DEFAULT NULL,
+    `DrivetrainType` int(11) DEFAULT NULL,
+    `NumCylinders` int(11) DEFAULT NULL,
+    `PositionX` float DEFAULT NULL,
+    `PositionY` float DEFAULT NULL,
+    `PositionZ` float DEFAULT NULL,
+    `Speed` float DEFAULT NULL,
+    `Power` float DEFAULT NULL,
+    `Torque` float DEFAULT NULL,
+    `TireTempFrontLeft` float DEFAULT NULL,
+    `TireTempFrontRight` float DEFAULT NULL,
+    `TireTempRearLeft` float DEFAULT NULL,
+    `TireTempRearRight` float DEFAULT NULL,
+    `Boost` float DEFAULT NULL,
+    `Fuel` float DEFAULT NULL,
+    `DistanceTraveled` float DEFAULT NULL,
+    `BestLap` float DEFAULT NULL,
+    `LastLap` float DEFAULT NULL,
+    `CurrentLap` float DEFAULT NULL,
+    `CurrentRaceTime` float DEFAULT NULL,
+    `LapNumber` smallint(5) unsigned DEFAULT NULL,
+    `RacePosition` tinyint(3) unsigned DEFAULT NULL,
+    `Accel` tinyint(3) unsigned DEFAULT NULL,
+    `Brake` tinyint(3) unsigned DEFAULT NULL,
+    `Clutch` tinyint(3) unsigned DEFAULT NULL,
+    `HandBrake` tinyint(3) unsigned DEFAULT NULL,
+    `Gear` tinyint(3) unsigned DEFAULT NULL,
+    `Steer` tinyint(4) DEFAULT NULL,
+    `NormalizedDrivingLine` tinyint(4) DEFAULT NULL,
+    `NormalizedAIBrakeDifference` tinyint(4) DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -0,0 +1,79 @@
+CREATE TABLE IF NOT EXISTS `tmd_granturismo7` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `CurrentGear` float DEFAULT NULL,
+    `SuggestedGear` float DEFAULT NULL,
+    `Magic` int(11) DEFAULT NULL,
+    `PositionX` float DEFAULT NULL,
+    `PositionY` float DEFAULT NULL,
+    `PositionZ` float DEFAULT NULL,
+    `VelocityX` float DEFAULT NULL,
+    `VelocityY` float DEFAULT NULL,
+    `VelocityZ` float DEFAULT NULL,
+    `RotationPitch` float DEFAULT NULL,
+    `RotationYaw` float DEFAULT NULL,
+    `RotationRoll` float DEFAULT NULL,
+    `RelativeOrientationToNorth` float DEFAULT NULL,
+    `AngularVelocityX` float DEFAULT NULL,
+    `AngularVelocityY` float DEFAULT NULL,
+    `AngularVelocityZ` float DEFAULT NULL,
+    `BodyHeight` float DEFAULT NULL,
+    `EngineRPM` float DEFAULT NULL,
+    `GasLevel` float DEFAULT NULL,
+    `GasCapacity` float DEFAULT NULL,
+    `MetersPerSecond` float DEFAULT NULL,
+    `TurboBoost` float DEFAULT NULL,
+    `OilPressure` float DEFAULT NULL,
+    `WaterTemperature` float DEFAULT NULL,
+    `OilTemperature` float DEFAULT NULL,
+    `TireSurfaceTemperatureFrontLeft` float DEFAULT NULL,
+    `TireSurfaceTemperatureFrontRight` float DEFAULT NULL,
+    `TireSurfaceTemperatureRearLeft` float DEFAULT NULL,
+    `TireSurfaceTemperatureRearRight` float DEFAULT NULL,
+    `PacketId` int(11) DEFAULT NULL,
+    `LapCount` smallint(6) DEFAULT NULL,
+    `LapsInRace` smallint(6) DEFAULT NULL,
+    `BestLapTime` int(11) DEFAULT NULL,
+    `LastLapTime` int(11) DEFAULT NULL,
+    `TimeOfDayProgression` int(11) DEFAULT NULL,
+    `PreRaceStartPosition` smallint(6) DEFAULT NULL,
+    `NumCarsAtPreRace` smallint(6) DEFAULT NULL,
+    `MinAlertRPM` smallint(6) DEFAULT NULL,
+    `MaxAlertRPM` smallint(6) DEFAULT NULL,
+    `CalculatedMaxSpeed` smallint(6) DEFAULT NULL,
+    `Flags` smallint(5) unsigned DEFAULT NULL,
+    `Gears` tinyint(3) unsigned DEFAULT NULL,
+    `Throttle` tinyint(3) unsigned DEFAULT NULL,
+    `Brake` tinyint(3) unsigned DEFAULT NULL,
+    `RoadPlaneX` float DEFAULT NULL,
+    `RoadPlaneY` float DEFAULT NULL,
+    `RoadPlaneZ` float DEFAULT NULL,
+    `RoadPlaneDistance` float DEFAULT NULL,
+    `WheelRevPerSecondFrontLeft` float DEFAULT NULL,
+    `WheelRevPerSecondFrontRight` float DEFAULT NULL,
+    `WheelRevPerSecondRearLeft` float DEFAULT NULL,
+    `WheelRevPerSecondRearRight` float DEFAULT NULL,
+    `TireRadiusFrontLeft` float DEFAULT NULL,
+    `TireRadiusFrontRight` float DEFAULT NULL,
+    `TireRadiusRearLeft` float DEFAULT NULL,
+    `TireRadiusRearRight` float DEFAULT NULL,
+    `SuspensionHeightFrontLeft` float DEFAULT NULL,
+    `SuspensionHeightFrontRight` float DEFAULT NULL,
+    `SuspensionHeightRearLeft` float DEFAULT NULL,
+    `SuspensionHeightRearRight` float DEFAULT NULL,
+    `ClutchPedal` float DEFAULT NULL,
+    `ClutchEngagement` float DEFAULT NULL,
+    `RPMFromClutchToGearbox` float DEFAULT NULL,
+    `TransmissionTopSpeed` float DEFAULT NULL,
+    `GearRatio1` float DEFAULT NULL,
+    `GearRatio2` float DEFAULT NULL,
+    `GearRatio3` float DEFAULT NULL,
+    `GearRatio4` float DEFAULT NULL,
+    `GearRatio5` float DEFAULT NULL,
+    `GearRatio6` float DEFAULT NULL,
+    `GearRatio7` float DEFAULT NULL,
+    `GearRatio8` float DEFAULT NULL,
+    `CarCode` int(11) DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
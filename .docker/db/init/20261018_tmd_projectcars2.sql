@@ -0,0 +1,215 @@
+CREATE TABLE IF NOT EXISTS `tmd_projectcars2` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `created_at` varchar(100) NOT NULL DEFAULT current_timestamp(),
+    `IsRaceOn` float DEFAULT NULL,
+    `PlayerParticipantIndex` float DEFAULT NULL,
+    `GameState` float DEFAULT NULL,
+    `SessionState` float DEFAULT NULL,
+    `Gear` float DEFAULT NULL,
+    `NumGears` float DEFAULT NULL,
+    `RacePosition` float DEFAULT NULL,
+    `Sector` float DEFAULT NULL,
+    `RaceState` float DEFAULT NULL,
+    `LapInvalidated` float DEFAULT NULL,
+    `ViewedParticipantIndex` float DEFAULT NULL,
+    `UnfilteredThrottle` float DEFAULT NULL,
+    `UnfilteredBrake` float DEFAULT NULL,
+    `UnfilteredSteering` float DEFAULT NULL,
+    `UnfilteredClutch` float DEFAULT NULL,
+    `CarFlags` float DEFAULT NULL,
+    `OilTempCelsius` float DEFAULT NULL,
+    `OilPressureKPa` float DEFAULT NULL,
+    `WaterTempCelsius` float DEFAULT NULL,
+    `WaterPressureKpa` float DEFAULT NULL,
+    `FuelPressureKpa` float DEFAULT NULL,
+    `FuelCapacity` float DEFAULT NULL,
+    `Brake` float DEFAULT NULL,
+    `Throttle` float DEFAULT NULL,
+    `Clutch` float DEFAULT NULL,
+    `FuelLevel` float DEFAULT NULL,
+    `Speed` float DEFAULT NULL,
+    `Rpm` float DEFAULT NULL,
+    `MaxRpm` float DEFAULT NULL,
+    `Steering` float DEFAULT NULL,
+    `GearNumGears` float DEFAULT NULL,
+    `BoostAmount` float DEFAULT NULL,
+    `CrashState` float DEFAULT NULL,
+    `OdometerKM` float DEFAULT NULL,
+    `OrientationX` float DEFAULT NULL,
+    `OrientationY` float DEFAULT NULL,
+    `OrientationZ` float DEFAULT NULL,
+    `LocalVelocityX` float DEFAULT NULL,
+    `LocalVelocityY` float DEFAULT NULL,
+    `LocalVelocityZ` float DEFAULT NULL,
+    `WorldVelocityX` float DEFAULT NULL,
+    `WorldVelocityY` float DEFAULT NULL,
+    `WorldVelocityZ` float DEFAULT NULL,
+    `AngularVelocityX` float DEFAULT NULL,
+    `AngularVelocityY` float DEFAULT NULL,
+    `AngularVelocityZ` float DEFAULT NULL,
+    `LocalAccelerationX` float DEFAULT NULL,
+    `LocalAccelerationY` float DEFAULT NULL,
+    `LocalAccelerationZ` float DEFAULT NULL,
+    `WorldAccelerationX` float DEFAULT NULL,
+    `WorldAccelerationY` float DEFAULT NULL,
+    `WorldAccelerationZ` float DEFAULT NULL,
+    `ExtentsCentreX` float DEFAULT NULL,
+    `ExtentsCentreY` float DEFAULT NULL,
+    `ExtentsCentreZ` float DEFAULT NULL,
+    `TyreFlagsFrontLeft` float DEFAULT NULL,
+    `TyreFlagsFrontRight` float DEFAULT NULL,
+    `TyreFlagsRearLeft` float DEFAULT NULL,
+    `TyreFlagsRearRight` float DEFAULT NULL,
+    `TerrainFrontLeft` float DEFAULT NULL,
+    `TerrainFrontRight` float DEFAULT NULL,
+    `TerrainRearLeft` float DEFAULT NULL,
+    `TerrainRearRight` float DEFAULT NULL,
+    `TyreYFrontLeft` float DEFAULT NULL,
+    `TyreYFrontRight` float DEFAULT NULL,
+    `TyreYRearLeft` float DEFAULT NULL,
+    `TyreYRearRight` float DEFAULT NULL,
+    `TyreRPSFrontLeft` float DEFAULT NULL,
+    `TyreRPSFrontRight` float DEFAULT NULL,
+    `TyreRPSRearLeft` float DEFAULT NULL,
+    `TyreRPSRearRight` float DEFAULT NULL,
+    `TyreTempFrontLeft` float DEFAULT NULL,
+    `TyreTempFrontRight` float DEFAULT NULL,
+    `TyreTempRearLeft` float DEFAULT NULL,
+    `TyreTempRearRight` float DEFAULT NULL,
+    `TyreHeightAboveGroundFrontLeft` float DEFAULT NULL,
+    `TyreHeightAboveGroundFrontRight` float DEFAULT NULL,
+    `TyreHeightAboveGroundRearLeft` float DEFAULT NULL,
+    `TyreHeightAboveGroundRearRight` float DEFAULT NULL,
+    `TyreWearFrontLeft` float DEFAULT NULL,
+    `TyreWearFrontRight` float DEFAULT NULL,
+    `TyreWearRearLeft` float DEFAULT NULL,
+    `TyreWearRearRight` float DEFAULT NULL,
+    `BrakeDamageFrontLeft` float DEFAULT NULL,
+    `BrakeDamageFrontRight` float DEFAULT NULL,
+    `BrakeDamageRearLeft` float DEFAULT NULL,
+    `BrakeDamageRearRight` float DEFAULT NULL,
+    `SuspensionDamageFrontLeft` float DEFAULT NULL,
+    `SuspensionDamageFrontRight` float DEFAULT NULL,
+    `SuspensionDamageRearLeft` float DEFAULT NULL,
+    `SuspensionDamageRearRight` float DEFAULT NULL,
+    `BrakeTempCelsiusFrontLeft` float DEFAULT NULL,
+    `BrakeTempCelsiusFrontRight` float DEFAULT NULL,
+    `BrakeTempCelsiusRearLeft` float DEFAULT NULL,
+    `BrakeTempCelsiusRearRight` float DEFAULT NULL,
+    `TyreTreadTempFrontLeft` float DEFAULT NULL,
+    `TyreTreadTempFrontRight` float DEFAULT NULL,
+    `TyreTreadTempRearLeft` float DEFAULT NULL,
+    `TyreTreadTempRearRight` float DEFAULT NULL,
+    `TyreLayerTempFrontLeft` float DEFAULT NULL,
+    `TyreLayerTempFrontRight` float DEFAULT NULL,
+    `TyreLayerTempRearLeft` float DEFAULT NULL,
+    `TyreLayerTempRearRight` float DEFAULT NULL,
+    `TyreCarcassTempFrontLeft` float DEFAULT NULL,
+    `TyreCarcassTempFrontRight` float DEFAULT NULL,
+    `TyreCarcassTempRearLeft` float DEFAULT NULL,
+    `TyreCarcassTempRearRight` float DEFAULT NULL,
+    `TyreRimTempFrontLeft` float DEFAULT NULL,
+    `TyreRimTempFrontRight` float DEFAULT NULL,
+    `TyreRimTempRearLeft` float DEFAULT NULL,
+    `TyreRimTempRearRight` float DEFAULT NULL,
+    `TyreInternalAirTempFrontLeft` float DEFAULT NULL,
+    `TyreInternalAirTempFrontRight` float DEFAULT NULL,
+    `TyreInternalAirTempRearLeft` float DEFAULT NULL,
+    `TyreInternalAirTempRearRight` float DEFAULT NULL,
+    `TyreTempLeftFrontLeft` float DEFAULT NULL,
+    `TyreTempLeftFrontRight` float DEFAULT NULL,
+    `TyreTempLeftRearLeft` float DEFAULT NULL,
+    `TyreTempLeftRearRight` float DEFAULT NULL,
+    `TyreTempCenterFrontLeft` float DEFAULT NULL,
+    `TyreTempCenterFrontRight` float DEFAULT NULL,
+    `TyreTempCenterRearLeft` float DEFAULT NULL,
+    `TyreTempCenterRearRight` float DEFAULT NULL,
+    `TyreTempRightFrontLeft` float DEFAULT NULL,
+    `TyreTempRightFrontRight` float DEFAULT NULL,
+    `TyreTempRightRearLeft` float DEFAULT NULL,
+    `TyreTempRightRearRight` float DEFAULT NULL,
+    `WheelLocalPositionYFrontLeft` float DEFAULT NULL,
+    `WheelLocalPositionYFrontRight` float DEFAULT NULL,
+    `WheelLocalPositionYRearLeft` float DEFAULT NULL,
+    `WheelLocalPositionYRearRight` float DEFAULT NULL,
+    `RideHeightFrontLeft` float DEFAULT NULL,
+    `RideHeightFrontRight` float DEFAULT NULL,
+    `RideHeightRearLeft` float DEFAULT NULL,
+    `RideHeightRearRight` float DEFAULT NULL,
+    `SuspensionTravelFrontLeft` float DEFAULT NULL,
+    `SuspensionTravelFrontRight` float DEFAULT NULL,
+    `SuspensionTravelRearLeft` float DEFAULT NULL,
+    `SuspensionTravelRearRight` float DEFAULT NULL,
+    `SuspensionVelocityFrontLeft` float DEFAULT NULL,
+    `SuspensionVelocityFrontRight` float DEFAULT NULL,
+    `SuspensionVelocityRearLeft` float DEFAULT NULL,
+    `SuspensionVelocityRearRight` float DEFAULT NULL,
+    `SuspensionRideHeightFrontLeft` float DEFAULT NULL,
+    `SuspensionRideHeightFrontRight` float DEFAULT NULL,
+    `SuspensionRideHeightRearLeft` float DEFAULT NULL,
+    `SuspensionRideHeightRearRight` float DEFAULT NULL,
+    `AirPressureFrontLeft` float DEFAULT NULL,
+    `AirPressureFrontRight` float DEFAULT NULL,
+    `AirPressureRearLeft` float DEFAULT NULL,
+    `AirPressureRearRight` float DEFAULT NULL,
+    `EngineSpeed` float DEFAULT NULL,
+    `EngineTorque` float DEFAULT NULL,
+    `WingFront` float DEFAULT NULL,
+    `WingRear` float DEFAULT NULL,
+    `HandBrake` float DEFAULT NULL,
+    `AeroDamage` float DEFAULT NULL,
+    `EngineDamage` float DEFAULT NULL,
+    `JoyPad0` float DEFAULT NULL,
+    `DPad` float DEFAULT NULL,
+    `TurboBoostPressure` float DEFAULT NULL,
+    `FullPositionX` float DEFAULT NULL,
+    `FullPositionY` float DEFAULT NULL,
+    `FullPositionZ` float DEFAULT NULL,
+    `BrakeBias` float DEFAULT NULL,
+    `TickCount` float DEFAULT NULL,
+    `WorldFastestLapTime` float DEFAULT NULL,
+    `PersonalFastestLapTime` float DEFAULT NULL,
+    `PersonalFastestSector1Time` float DEFAULT NULL,
+    `PersonalFastestSector2Time` float DEFAULT NULL,
+    `PersonalFastestSector3Time` float DEFAULT NULL,
+    `WorldFastestSector1Time` float DEFAULT NULL,
+    `WorldFastestSector2Time` float DEFAULT NULL,
+    `WorldFastestSector3Time` float DEFAULT NULL,
+    `TrackLength` float DEFAULT NULL,
+    `LapsTimeInEvent` float DEFAULT NULL,
+    `EnforcedPitStopLap` float DEFAULT NULL,
+    `BuildVersionNumber` float DEFAULT NULL,
+    `GameSessionState` float DEFAULT NULL,
+    `AmbientTemperature` float DEFAULT NULL,
+    `TrackTemperature` float DEFAULT NULL,
+    `RainDensity` float DEFAULT NULL,
+    `SnowDensity` float DEFAULT NULL,
+    `WindSpeed` float DEFAULT NULL,
+    `WindDirectionX` float DEFAULT NULL,
+    `WindDirectionY` float DEFAULT NULL,
+    `NumParticipants` float DEFAULT NULL,
+    `ParticipantsChangedTimestamp` float DEFAULT NULL,
+    `EventTimeRemaining` float DEFAULT NULL,
+    `SplitTimeAhead` float DEFAULT NULL,
+    `SplitTimeBehind` float DEFAULT NULL,
+    `SplitTime` float DEFAULT NULL,
+    `CurrentLapDistance` float DEFAULT NULL,
+    `RacePositionFlags` float DEFAULT NULL,
+    `SectorFlags` float DEFAULT NULL,
+    `HighestFlag` float DEFAULT NULL,
+    `PitModeSchedule` float DEFAULT NULL,
+    `CarIndex` float DEFAULT NULL,
+    `RaceStateFlags` float DEFAULT NULL,
+    `CurrentLap` float DEFAULT NULL,
+    `CurrentTime` float DEFAULT NULL,
+    `CurrentSectorTime` float DEFAULT NULL,
+    `MPParticipantIndex` float DEFAULT NULL,
+    `FastestLapTime` float DEFAULT NULL,
+    `LastLapTime` float DEFAULT NULL,
+    `LastSectorTime` float DEFAULT NULL,
+    `FastestSector1Time` float DEFAULT NULL,
+    `FastestSector2Time` float DEFAULT NULL,
+    `FastestSector3Time` float DEFAULT NULL,
+    `ParticipantOnlineRep` float DEFAULT NULL,
+   PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
@@ -1,13 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/supervisor"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/ac"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/acc"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/f1"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fh"
 	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/gt7"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/outgauge"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/pcars2"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/rally"
 	sentry "github.com/getsentry/sentry-go"
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -26,25 +40,100 @@ func main() {
 	debugMode := os.Getenv("DEBUG_MODE")
 	fmt.Printf("USER_ID:%+v\n", os.Getenv("USER_ID"))
 
-	fm := fms2023.NewForzaMotorsportHandler(debugMode)
+	sv := supervisor.NewSupervisor()
+	sv.OnError = func(_ supervisor.Listener, err error) {
+		sentry.CaptureException(err)
+	}
 
-	forzaMotorsportPort := getIntPort(os.Getenv("TMD_FORZAM"))
-	if forzaMotorsportPort != -1 {
-		err := fm.InitAndRun(forzaMotorsportPort)
-		if err != nil {
-			log.Fatalln(err)
-		}
+	for _, port := range getIntPorts(os.Getenv("TMD_FORZAM")) {
+		sv.Add(enums.Games.ForzaMotorsport2023(), port, fms2023.NewForzaMotorsportHandler(debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_FORZAH")) {
+		sv.Add(enums.Games.ForzaHorizon(), port, fh.NewForzaHorizonHandler(debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_F1")) {
+		sv.Add(enums.Games.F1(), port, f1.NewF1Handler(debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_GT7")) {
+		sv.Add(enums.Games.GranTurismo7(), port, gt7.NewGT7Handler(os.Getenv("TMD_GT7_PLAYSTATION"), debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_AC")) {
+		sv.Add(enums.Games.AssettoCorsa(), port, ac.NewAssettoCorsaHandler(
+			os.Getenv("TMD_AC_HOST"), os.Getenv("TMD_AC_MODE"), debugMode,
+		))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_ACC")) {
+		sv.Add(enums.Games.AssettoCorsaCompetizione(), port, acc.NewACCHandler(
+			os.Getenv("TMD_ACC_HOST"), os.Getenv("TMD_ACC_PASSWORD"), debugMode,
+		))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_PCARS2")) {
+		sv.Add(enums.Games.ProjectCars2(), port, pcars2.NewPCars2Handler(debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_DR2")) {
+		sv.Add(enums.Games.DirtRally2(), port, rally.NewDirtRally2Handler(debugMode))
+	}
+	for _, port := range getIntPorts(os.Getenv("TMD_WRC")) {
+		sv.Add(enums.Games.EAWRC(), port, rally.NewWRCHandler(debugMode))
+	}
+	lfsOutSimPorts := getIntPorts(os.Getenv("TMD_LFS_OUTSIM"))
+	for i, port := range getIntPorts(os.Getenv("TMD_LFS")) {
+		sv.Add(enums.Games.LiveForSpeed(), port, outgauge.NewLiveForSpeedHandler(portAt(lfsOutSimPorts, i), debugMode))
 	}
+	beamNGOutSimPorts := getIntPorts(os.Getenv("TMD_BEAMNG_OUTSIM"))
+	for i, port := range getIntPorts(os.Getenv("TMD_BEAMNG")) {
+		sv.Add(enums.Games.BeamNG(), port, outgauge.NewBeamNGHandler(portAt(beamNGOutSimPorts, i), debugMode))
+	}
+
+	if len(sv.Listeners()) == 0 {
+		fatalf("No game listeners configured")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// a second signal kills the app without waiting for the adapters
+		stop()
+		log.Println("Shutting down, writing the received data...")
+	}()
+
+	errs := sv.Run(ctx)
+	if len(errs) > 0 {
+		fatalf("%d of %d listeners failed", len(errs), len(sv.Listeners()))
+	}
+	log.Printf("%d listeners stopped", len(sv.Listeners()))
 }
 
-func getIntPort(portEnv string) int {
-	if portEnv != "" {
-		port, err := strconv.Atoi(portEnv)
+// getIntPorts parses a comma separated list of ports, eg. 9999,9998
+func getIntPorts(portEnv string) []int {
+	var ports []int
+	if portEnv == "" {
+		return ports
+	}
+
+	for _, portValue := range strings.Split(portEnv, ",") {
+		port, err := strconv.Atoi(strings.TrimSpace(portValue))
 		if err != nil {
-			log.Fatalln(err)
+			fatalf("Invalid port in %q: %s", portEnv, err)
 		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// fatalf logs the message, sends the captured errors to Sentry and exits.
+// log.Fatalf would exit without running the deferred calls, so the errors would not be sent.
+func fatalf(format string, v ...any) {
+	log.Printf(format, v...)
+	sentry.Flush(2 * time.Second)
+	os.Exit(1)
+}
 
-		return port
+// portAt returns the port at the index or 0 when there are fewer ports
+func portAt(ports []int, index int) int {
+	if index < len(ports) {
+		return ports[index]
 	}
-	return -1
+	return 0
 }
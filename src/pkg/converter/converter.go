@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"log"
 	"os"
 	"strings"
@@ -17,6 +18,46 @@ var gameEnvKeys = map[enums.Game]gameConfiguration{
 		AdaptersEnvKey: "TMD_FORZAM_ADAPTERS",
 		DatabaseTable:  "tmd_forzamotorsport2023",
 	},
+	enums.Games.ForzaHorizon(): {
+		AdaptersEnvKey: "TMD_FORZAH_ADAPTERS",
+		DatabaseTable:  "tmd_forzahorizon",
+	},
+	enums.Games.F1(): {
+		AdaptersEnvKey: "TMD_F1_ADAPTERS",
+		DatabaseTable:  "tmd_f1",
+	},
+	enums.Games.GranTurismo7(): {
+		AdaptersEnvKey: "TMD_GT7_ADAPTERS",
+		DatabaseTable:  "tmd_granturismo7",
+	},
+	enums.Games.AssettoCorsa(): {
+		AdaptersEnvKey: "TMD_AC_ADAPTERS",
+		DatabaseTable:  "tmd_assettocorsa",
+	},
+	enums.Games.AssettoCorsaCompetizione(): {
+		AdaptersEnvKey: "TMD_ACC_ADAPTERS",
+		DatabaseTable:  "tmd_assettocorsacompetizione",
+	},
+	enums.Games.ProjectCars2(): {
+		AdaptersEnvKey: "TMD_PCARS2_ADAPTERS",
+		DatabaseTable:  "tmd_projectcars2",
+	},
+	enums.Games.DirtRally2(): {
+		AdaptersEnvKey: "TMD_DR2_ADAPTERS",
+		DatabaseTable:  "tmd_dirtrally2",
+	},
+	enums.Games.EAWRC(): {
+		AdaptersEnvKey: "TMD_WRC_ADAPTERS",
+		DatabaseTable:  "tmd_eawrc",
+	},
+	enums.Games.LiveForSpeed(): {
+		AdaptersEnvKey: "TMD_LFS_ADAPTERS",
+		DatabaseTable:  "tmd_liveforspeed",
+	},
+	enums.Games.BeamNG(): {
+		AdaptersEnvKey: "TMD_BEAMNG_ADAPTERS",
+		DatabaseTable:  "tmd_beamng",
+	},
 }
 
 type gameConfiguration struct {
@@ -25,7 +66,7 @@ type gameConfiguration struct {
 }
 
 type ConverterInterface interface {
-	ChannelInit(now time.Time, channel chan telemetry.GameData, port int)
+	ChannelInit(ctx context.Context, now time.Time, channel chan telemetry.GameData, port int)
 	Convert(now time.Time, data telemetry.GameData, port int)
 }
 
@@ -33,6 +74,27 @@ type ConverterData struct {
 	GameName enums.Game
 }
 
+// flushPeriodically calls flush every interval until ctx is cancelled, the returned channel is closed then
+func flushPeriodically(ctx context.Context, interval time.Duration, flush func() error) <-chan struct{} {
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := flush(); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+	}()
+	return flushed
+}
+
 // SetupAdapter sets up game adapters like CSV export, MySQL export, etc.
 func SetupAdapter(game enums.Game) []telemetry.ConverterInterface {
 	adapters := strings.Split(os.Getenv(gameEnvKeys[game].AdaptersEnvKey), ",")
@@ -74,6 +136,54 @@ func SetupAdapter(game enums.Game) []telemetry.ConverterInterface {
 			}
 			converters = append(converters, config)
 			log.Printf("[%s] UDP adapter configured", game)
+		case "forza":
+			config, err := NewForzaForwarder(game, adapterConfiguration)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] Forza adapter configured", game)
+		case "influx":
+			config, err := NewInfluxConverter(game, adapterConfiguration)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] InfluxDB adapter configured", game)
+		case "prometheus":
+			config, err := NewPrometheusExporter(game, adapterConfiguration)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] Prometheus adapter configured", game)
+		case "postgres":
+			config, err := NewPostgresConverter(game, adapterConfiguration)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] PostgreSQL adapter configured", game)
+		case "sqlite":
+			config, err := NewSQLiteConverter(game, adapterConfiguration)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] SQLite adapter configured", game)
+		case "parquet":
+			config, err := NewParquetConverter(game, adapterConfiguration, afero.NewOsFs())
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			converters = append(converters, config)
+			log.Printf("[%s] Parquet adapter configured", game)
 		}
 	}
 	return converters
@@ -1,11 +1,16 @@
 package converter
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
@@ -13,18 +18,21 @@ import (
 	"github.com/spf13/afero"
 )
 
-var (
-	ErrInvalidCsvAdapterConfiguration = errors.New("[CSV] invalid adapter configuration")
-	ErrInvalidFilePath                = errors.New("[CSV] invalid file path")
-	ErrInvalidRetention               = errors.New("[CSV] invalid retention type")
-)
+var ErrInvalidCsvAdapterConfiguration = errors.New("[CSV] invalid adapter configuration")
 
+// CsvConverter writes the data to the CSV files with the retention. Every instance of the game, received
+// on its own port, writes to its own file, eg. fms2023-daily-2026-10-18-9999.csv. The header is made of
+// the keys of the first packet, when a packet has other keys a new part of the file is started,
+// eg. fms2023-daily-2026-10-18-9999.1.csv.
 type CsvConverter struct {
 	ConverterData
 	Fs          afero.Fs
 	FilePath    string
 	Retention   enums.RetentionType
 	fileHandler afero.File
+	// retentionPath is the file path of the retention, keys are the columns of the open file
+	retentionPath string
+	keys          []string
 }
 
 func NewCsvConverter(game enums.Game, adapterConfiguration []string, fs afero.Fs) (*CsvConverter, error) {
@@ -41,121 +49,105 @@ func NewCsvConverter(game enums.Game, adapterConfiguration []string, fs afero.Fs
 	}, nil
 }
 
-func (csv *CsvConverter) ChannelInit(now time.Time, channel chan telemetry.GameData, port int) {
+func (csv *CsvConverter) ChannelInit(ctx context.Context, now time.Time, channel chan telemetry.GameData, port int) {
 	fmt.Println("CsvConverter ChannelInit")
-	//nolint:gosimple // loop is needed to keep the channel open
-	for {
-		select {
-		case data := <-channel:
-			csv.Convert(now, data, port)
-		}
-	}
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		csv.Convert(now, data, port)
+		data.Release()
+	})
 }
 
-// Convert the data to CSV format and writes it to the file
-func (csv *CsvConverter) Convert(now time.Time, data telemetry.GameData, _ int) {
-	afs := &afero.Afero{Fs: csv.Fs}
+// Convert the data to CSV format and writes it to the file of the instance
+func (csv *CsvConverter) Convert(now time.Time, data telemetry.GameData, port int) {
 	filePath, err := csv.CorrectFilePath(now)
 	if err != nil {
 		log.Fatalln(err)
 		return
 	}
 
-	fileExists, err := afs.Exists(filePath)
-	if err != nil {
-		log.Fatalln(err)
-		return
-	}
-	if !fileExists {
-		csvHeader := ""
-		for _, key := range data.Keys {
-			csvHeader += fmt.Sprintf(",%s", key)
-		}
-		csvHeader = csvHeader + "\n"
-		err = afs.WriteFile(filePath, []byte(csvHeader)[1:], 0o644)
-		if err != nil {
-			log.Fatalln(err)
-			return
+	if csv.fileHandler == nil || csv.retentionPath != filePath || !slices.Equal(csv.keys, data.Keys) {
+		// the retention has switched to a new file or the keys have changed
+		if err = csv.Close(); err != nil {
+			log.Println(err)
 		}
-	}
-
-	if csv.fileHandler == nil || csv.fileHandler.Name() != filePath {
-		csv.fileHandler, err = afs.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0o644)
-		if err != nil {
+		if err = csv.openFile(filePath, data.Keys, port); err != nil {
 			log.Fatalln(err)
 			return
 		}
-		defer csv.fileHandler.Close()
 	}
 
 	csvLine := ""
 	for _, key := range data.Keys {
-		csvLine += fmt.Sprintf(",%v", data.Data[key])
+		csvLine += "," + data.Format(key)
 	}
 	csvLine += "\n"
 	fmt.Fprint(csv.fileHandler, csvLine[1:])
 }
 
-// CorrectFilePath returns the correct file path based on the retention type
-func (csv *CsvConverter) CorrectFilePath(now time.Time) (string, error) {
+// openFile opens the first part of the instance file with the header of the keys, the part is created
+// when it does not exist. The existing parts with another header are kept.
+func (csv *CsvConverter) openFile(filePath string, keys []string, port int) error {
 	afs := &afero.Afero{Fs: csv.Fs}
-
-	switch csv.Retention {
-	case enums.RetentionTypes.Daily():
-		return csv.dailyRetention(now, afs)
-	case enums.RetentionTypes.None():
-		return csv.noRetention(now, afs)
-	}
-
-	return "", ErrInvalidRetention
-}
-
-// dailyRetention validate and returns the file path for daily retention
-func (csv *CsvConverter) dailyRetention(now time.Time, afs *afero.Afero) (string, error) {
-	isDir, err := afs.IsDir(csv.FilePath)
-	if err != nil || !isDir {
-		return "", ErrInvalidFilePath
+	header := strings.Join(keys, ",") + "\n"
+	extension := filepath.Ext(filePath)
+	instancePath := fmt.Sprintf("%s-%d", strings.TrimSuffix(filePath, extension), port)
+	name := instancePath + extension
+	for part := 1; ; part++ {
+		fileHeader, err := readHeader(afs, name)
+		if errors.Is(err, os.ErrNotExist) {
+			if err = afs.WriteFile(name, []byte(header), 0o644); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if fileHeader == header {
+			break
+		}
+		name = fmt.Sprintf("%s.%d%s", instancePath, part, extension)
 	}
 
-	defaultFileName := fmt.Sprintf("%s-daily-%s.csv", csv.GameName, now.Format("2006-01-02"))
-
-	slashAtTheEnd := csv.FilePath[len(csv.FilePath)-1:]
-	if slashAtTheEnd != "/" {
-		csv.FilePath += "/"
+	file, err := afs.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
 	}
-
-	return csv.FilePath + defaultFileName, nil
+	csv.fileHandler = file
+	csv.retentionPath = filePath
+	csv.keys = slices.Clone(keys)
+	return nil
 }
 
-// noRetention validate and returns the file path for no retention type
-func (csv *CsvConverter) noRetention(_ time.Time, afs *afero.Afero) (string, error) {
-	defaultFileName := fmt.Sprintf("%s.csv", csv.GameName)
-
-	dir, file := filepath.Split(csv.FilePath)
-
-	if file == "" {
-		isDir, err := afs.IsDir(dir)
-		if err != nil || !isDir {
-			return "", ErrInvalidFilePath
-		}
-
-		return csv.FilePath + defaultFileName, nil
+// readHeader returns the first line of the file with the line break
+func readHeader(afs *afero.Afero, name string) (string, error) {
+	file, err := afs.Open(name)
+	if err != nil {
+		return "", err
 	}
+	defer file.Close()
 
-	fileExt := filepath.Ext(csv.FilePath)
-	isDir, err := afs.IsDir(csv.FilePath)
-	if fileExt != ".csv" && (err != nil || !isDir) {
-		return "", ErrInvalidFilePath
+	header, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
 	}
+	return header, nil
+}
 
-	if fileExt == ".csv" {
-		return csv.FilePath, nil
+// Close flushes and closes the current file
+func (csv *CsvConverter) Close() error {
+	if csv.fileHandler == nil {
+		return nil
 	}
-
-	slashAtTheEnd := csv.FilePath[len(csv.FilePath)-1:]
-	if slashAtTheEnd != "/" {
-		csv.FilePath += "/"
+	err := csv.fileHandler.Sync()
+	if closeErr := csv.fileHandler.Close(); err == nil {
+		err = closeErr
 	}
+	csv.fileHandler, csv.retentionPath, csv.keys = nil, "", nil
+	return err
+}
 
-	return csv.FilePath + defaultFileName, nil
+// CorrectFilePath returns the correct file path based on the retention type
+func (csv *CsvConverter) CorrectFilePath(now time.Time) (string, error) {
+	return retentionFilePath(&afero.Afero{Fs: csv.Fs}, csv.GameName, csv.FilePath, csv.Retention, ".csv", now)
 }
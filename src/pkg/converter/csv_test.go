@@ -9,6 +9,7 @@ import (
 	"github.com/bluemanos/simracing-telemetry/src/telemetry"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //nolint:errcheck
@@ -198,9 +199,80 @@ func TestCsvConvert(t *testing.T) {
 		RawData: []byte("test,test2\n1,123.45\n"),
 	}, 1234)
 
-	fileExists, _ := afero.Exists(fs, "/var/www/simracing-telemetry/test.csv")
+	// the file of the instance is named with its port
+	fileExists, _ := afero.Exists(fs, "/var/www/simracing-telemetry/test-1234.csv")
 	assert.True(t, fileExists)
 
-	theFile, _ := afero.ReadFile(fs, "/var/www/simracing-telemetry/test.csv")
+	theFile, _ := afero.ReadFile(fs, "/var/www/simracing-telemetry/test-1234.csv")
 	assert.Equal(t, "test,test2\n1,123.45\n", string(theFile))
+
+	// the file is kept open for the next lines
+	converter.Convert(now, telemetry.GameData{
+		Keys: []string{"test", "test2"},
+		Data: map[string]float32{"test": 2, "test2": 0.5},
+	}, 1234)
+	theFile, _ = afero.ReadFile(fs, "/var/www/simracing-telemetry/test-1234.csv")
+	assert.Equal(t, "test,test2\n1,123.45\n2,0.5\n", string(theFile))
+
+	// the typed values are written without the float32 precision loss
+	converter.FilePath = "/var/www/simracing-telemetry/typed.csv"
+	converter.Convert(now, telemetry.GameData{
+		Keys: []string{"test", "test2"},
+		Data: map[string]float32{"test": 38404608, "test2": -2},
+		Values: map[string]telemetry.Value{
+			"test":  {DataType: "U32", Int: 38404609},
+			"test2": {DataType: "S32", Int: -2},
+		},
+	}, 1234)
+
+	theFile, _ = afero.ReadFile(fs, "/var/www/simracing-telemetry/typed-1234.csv")
+	assert.Equal(t, "test,test2\n38404609,-2\n", string(theFile))
+
+	assert.NoError(t, converter.Close())
+	assert.NoError(t, converter.Close())
+}
+
+//nolint:errcheck
+func TestCsvConvert_KeysChange(t *testing.T) {
+	now := time.Date(2023, 12, 24, 0, 1, 2, 333, time.UTC)
+
+	fs := afero.NewMemMapFs()
+	fs.MkdirAll("/var/www/simracing-telemetry", 0o755)
+	newConverter := func() *converter.CsvConverter {
+		return &converter.CsvConverter{
+			ConverterData: converter.ConverterData{GameName: enums.Games.ForzaMotorsport2023()},
+			Fs:            fs,
+			FilePath:      "/var/www/simracing-telemetry",
+			Retention:     enums.RetentionTypes.Daily(),
+		}
+	}
+	short := telemetry.GameData{Keys: []string{"Speed"}, Data: map[string]float32{"Speed": 1}}
+	long := telemetry.GameData{
+		Keys: []string{"Speed", "TireWearFrontLeft"}, Data: map[string]float32{"Speed": 2, "TireWearFrontLeft": 0.5},
+	}
+
+	first, second := newConverter(), newConverter()
+	first.Convert(now, short, 9999)
+	// the keys have changed, eg. the game sends the longer format, so a new part is started
+	first.Convert(now, long, 9999)
+	first.Convert(now, short, 9999)
+	// the other instance does not write to the file of the first one
+	second.Convert(now, long, 9998)
+	assert.NoError(t, first.Close())
+	assert.NoError(t, second.Close())
+
+	// the part with the same header is appended after a restart
+	restarted := newConverter()
+	restarted.Convert(now, long, 9999)
+	assert.NoError(t, restarted.Close())
+
+	for name, expected := range map[string]string{
+		"fms2023-daily-2023-12-24-9999.csv":   "Speed\n1\n1\n",
+		"fms2023-daily-2023-12-24-9999.1.csv": "Speed,TireWearFrontLeft\n2,0.5\n2,0.5\n",
+		"fms2023-daily-2023-12-24-9998.csv":   "Speed,TireWearFrontLeft\n2,0.5\n",
+	} {
+		theFile, err := afero.ReadFile(fs, "/var/www/simracing-telemetry/"+name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(theFile), name)
+	}
 }
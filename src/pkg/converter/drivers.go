@@ -0,0 +1,45 @@
+package converter
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DriversEnvKey maps the addresses of the games to the user IDs of the drivers, eg. 192.168.1.10=1,192.168.1.11=2
+const DriversEnvKey = "TMD_DRIVERS"
+
+var ErrInvalidDriversConfiguration = errors.New("[Drivers] invalid configuration")
+
+// Drivers contains the user IDs of the drivers by the address of their PC or console
+type Drivers map[netip.Addr]string
+
+// ParseDrivers reads the `address=user ID` pairs separated by `,`
+func ParseDrivers(configuration string) (Drivers, error) {
+	drivers := Drivers{}
+	if configuration == "" {
+		return drivers, nil
+	}
+
+	for _, driver := range strings.Split(configuration, ",") {
+		address, userID, ok := strings.Cut(strings.TrimSpace(driver), "=")
+		if !ok || userID == "" {
+			return nil, errors.Wrapf(ErrInvalidDriversConfiguration, "%s", driver)
+		}
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidDriversConfiguration, "%s: %v", driver, err)
+		}
+		drivers[addr] = userID
+	}
+	return drivers, nil
+}
+
+// UserID returns the user ID of the driver sending from the source, defaultUserID for the unknown sources
+func (d Drivers) UserID(source netip.AddrPort, defaultUserID string) string {
+	if userID, ok := d[source.Addr()]; ok {
+		return userID
+	}
+	return defaultUserID
+}
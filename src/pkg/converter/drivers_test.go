@@ -0,0 +1,29 @@
+package converter_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDrivers(t *testing.T) {
+	drivers, err := converter.ParseDrivers("192.168.1.10=1, 192.168.1.11=2")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", drivers.UserID(netip.MustParseAddrPort("192.168.1.10:50001"), "7"))
+	assert.Equal(t, "2", drivers.UserID(netip.MustParseAddrPort("192.168.1.11:60000"), "7"))
+	assert.Equal(t, "7", drivers.UserID(netip.MustParseAddrPort("192.168.1.12:50001"), "7"))
+	assert.Equal(t, "7", drivers.UserID(netip.AddrPort{}, "7"), "the data of a game without the address")
+
+	drivers, err = converter.ParseDrivers("")
+	require.NoError(t, err)
+	assert.Empty(t, drivers)
+
+	for _, configuration := range []string{"192.168.1.10", "192.168.1.10=", "console=1"} {
+		_, err = converter.ParseDrivers(configuration)
+		assert.ErrorIs(t, err, converter.ErrInvalidDriversConfiguration, configuration)
+	}
+}
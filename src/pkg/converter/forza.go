@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023/formats"
+)
+
+// ForzaForwarder transcodes the data of any game to the Forza Dash packet and sends it to the UDP clients
+type ForzaForwarder struct {
+	UdpForwarder
+	schema  *telemetry.Schema
+	started time.Time
+}
+
+// NewForzaForwarder creates a new ForzaForwarder, the clients are configured as in the UDP adapter
+func NewForzaForwarder(game enums.Game, adapterConfiguration []string) (*ForzaForwarder, error) {
+	clients, err := parseUdpClients(game, adapterConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := telemetry.LoadSchema(formats.FS(), formats.DataFormatFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForzaForwarder{
+		UdpForwarder: UdpForwarder{
+			ConverterData: ConverterData{GameName: game},
+			Clients:       clients,
+		},
+		schema:  schema,
+		started: time.Now(),
+	}, nil
+}
+
+func (forza *ForzaForwarder) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("ForzaForwarder ChannelInit")
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		forza.Convert(now, data, port)
+		data.Release()
+	})
+}
+
+// Convert transcodes the normalized sample of the player car to the Forza Dash packet
+// and sends it to the UDP clients. The data without the normalized sample, eg. a lap summary, is skipped.
+func (forza *ForzaForwarder) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	if data.Normalized == nil || !data.Normalized.IsPlayer {
+		return
+	}
+	forza.send(forza.Transcode(data.Normalized))
+}
+
+// Transcode builds the Forza Dash packet from the normalized sample
+func (forza *ForzaForwarder) Transcode(sample *telemetry.Normalized) []byte {
+	fields := map[string]float32{
+		"IsRaceOn":         boolValue(sample.IsRaceOn),
+		"EngineMaxRpm":     sample.MaxRPM,
+		"EngineIdleRpm":    sample.IdleRPM,
+		"CurrentEngineRpm": sample.RPM,
+		"Yaw":              sample.Yaw,
+		"Pitch":            sample.Pitch,
+		"Roll":             sample.Roll,
+		"Speed":            sample.Speed,
+		"Fuel":             sample.Fuel,
+		"DistanceTraveled": sample.Distance,
+		"BestLap":          sample.BestLap,
+		"LastLap":          sample.LastLap,
+		"CurrentLap":       sample.CurrentLap,
+		"LapNumber":        float32(sample.LapNumber),
+		"RacePosition":     float32(sample.RacePosition),
+		"Accel":            sample.Throttle * 255,
+		"Brake":            sample.Brake * 255,
+		"Clutch":           sample.Clutch * 255,
+		"HandBrake":        sample.HandBrake * 255,
+		"Gear":             forzaGear(sample.Gear),
+		"Steer":            sample.Steer * 127,
+		"CarOrdinal":       float32(sample.CarID),
+		"CarClass":         float32(sample.CarClass),
+		"TrackOrdinal":     float32(sample.TrackID),
+	}
+	for prefix, vector := range map[string]telemetry.Vector3{
+		"Position":     sample.Position,
+		"Velocity":     sample.Velocity,
+		"Acceleration": sample.Acceleration,
+	} {
+		fields[prefix+"X"], fields[prefix+"Y"], fields[prefix+"Z"] = vector.X, vector.Y, vector.Z
+	}
+	for i, suffix := range telemetry.WheelSuffixes {
+		fields["WheelRotationSpeed"+suffix] = sample.WheelSpeed[i]
+		fields["SuspensionTravelMeters"+suffix] = sample.SuspensionTravel[i]
+		if sample.TireTemperature[i] != 0 {
+			// Forza sends the temperature in Fahrenheit
+			fields["TireTemp"+suffix] = sample.TireTemperature[i]*9/5 + 32
+		}
+	}
+
+	values := make(map[string]telemetry.Value, len(fields)+1)
+	for key, value := range fields {
+		values[key] = telemetry.Value{DataType: "F32", Float: float64(value)}
+	}
+	// the milliseconds exceed the float32 precision after 4.6 hours, the timestamp wraps as the game one
+	values["TimestampMS"] = telemetry.Value{
+		DataType: "U32", Int: int64(uint32(time.Since(forza.started).Milliseconds())),
+	}
+	return forza.schema.EncodeValues(values)
+}
+
+// forzaGear converts the normalized gear, Forza sends the reverse as 0
+func forzaGear(gear int) float32 {
+	switch {
+	case gear < 0:
+		return 0
+	case gear == 0:
+		return formats.NeutralGear
+	}
+	return float32(gear)
+}
+
+func boolValue(value bool) float32 {
+	if value {
+		return 1
+	}
+	return 0
+}
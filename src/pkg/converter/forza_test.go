@@ -0,0 +1,108 @@
+package converter_test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023/formats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForzaForwarder_Transcode(t *testing.T) {
+	dash, err := telemetry.LoadSchema(formats.FS(), formats.DataFormatFile)
+	require.NoError(t, err)
+	require.Equal(t, 331, dash.Size)
+
+	forwarder, err := converter.NewForzaForwarder(enums.Games.F1(), []string{"forza", "127.0.0.1", "5300"})
+	require.NoError(t, err)
+
+	sample := &telemetry.Normalized{
+		IsRaceOn:        true,
+		IsPlayer:        true,
+		Speed:           50,
+		RPM:             11000,
+		MaxRPM:          13000,
+		Gear:            3,
+		Throttle:        1,
+		Steer:           -1,
+		Fuel:            0.25,
+		Position:        telemetry.Vector3{X: 12.5, Y: 3, Z: -40},
+		Acceleration:    telemetry.Vector3{Z: 9.80665},
+		TireTemperature: telemetry.Wheels{90, 90, 100, 100},
+		LapNumber:       2,
+		RacePosition:    4,
+		LastLap:         81.234,
+		CarID:           2345,
+		TrackID:         11,
+	}
+
+	t.Run("the normalized sample is kept", func(t *testing.T) {
+		packet := forwarder.Transcode(sample)
+		require.Len(t, packet, dash.Size)
+
+		normalized := fms2023.Normalize(dash.Decode(packet))
+		assert.Equal(t, sample.Gear, normalized.Gear)
+		assert.Equal(t, sample.Position, normalized.Position)
+		assert.Equal(t, sample.Acceleration, normalized.Acceleration)
+		assert.Equal(t, sample.LapNumber, normalized.LapNumber)
+		assert.Equal(t, sample.RacePosition, normalized.RacePosition)
+		assert.Equal(t, sample.CarID, normalized.CarID)
+		assert.Equal(t, sample.TrackID, normalized.TrackID)
+		assert.InDelta(t, sample.Throttle, normalized.Throttle, 0.001)
+		assert.InDelta(t, sample.Steer, normalized.Steer, 0.001)
+		assert.InDelta(t, sample.LastLap, normalized.LastLap, 0.001)
+		assert.InDeltaSlice(t, sample.TireTemperature[:], normalized.TireTemperature[:], 0.001)
+	})
+
+	t.Run("gears", func(t *testing.T) {
+		for gear, expected := range map[int]float32{-1: 0, 0: fms2023.NeutralGear, 1: 1, 6: 6} {
+			values := dash.Decode(forwarder.Transcode(&telemetry.Normalized{Gear: gear}))
+			assert.Equal(t, expected, values["Gear"], gear)
+		}
+	})
+}
+
+func TestForzaForwarder_Convert(t *testing.T) {
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer client.Close()
+	port := client.LocalAddr().(*net.UDPAddr).Port
+
+	forwarder, err := converter.NewForzaForwarder(
+		enums.Games.AssettoCorsaCompetizione(), []string{"forza", "127.0.0.1", strconv.Itoa(port)},
+	)
+	require.NoError(t, err)
+
+	// the other cars and the data without the normalized sample are not sent
+	forwarder.Convert(time.Now(), telemetry.GameData{
+		Normalized: &telemetry.Normalized{IsRaceOn: true, Speed: 10},
+	}, 9000)
+	forwarder.Convert(time.Now(), telemetry.GameData{Data: map[string]float32{"Lap": 3}}, 9000)
+	forwarder.Convert(time.Now(), telemetry.GameData{Normalized: &telemetry.Normalized{
+		IsRaceOn: true, IsPlayer: true, Speed: 60, Gear: 4, RacePosition: 2,
+	}}, 9000)
+
+	require.NoError(t, client.SetReadDeadline(time.Now().Add(time.Second)))
+	buffer := make([]byte, 1024)
+	n, err := client.Read(buffer)
+	require.NoError(t, err)
+	require.Equal(t, 331, n)
+
+	dash, err := telemetry.LoadSchema(formats.FS(), formats.DataFormatFile)
+	require.NoError(t, err)
+	values := dash.Decode(buffer[:n])
+	assert.Equal(t, float32(1), values["IsRaceOn"])
+	assert.Equal(t, float32(60), values["Speed"])
+	assert.Equal(t, float32(4), values["Gear"])
+	assert.Equal(t, float32(2), values["RacePosition"])
+	timestamp := dash.DecodeValues(buffer[:n])["TimestampMS"]
+	assert.Equal(t, "U32", timestamp.DataType)
+	assert.Less(t, timestamp.Int, int64(time.Second/time.Millisecond), "the time since the forwarder was created")
+}
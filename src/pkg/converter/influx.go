@@ -0,0 +1,220 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// InfluxBatchSize is the number of points sent in a single write request
+	InfluxBatchSize = 500
+	// InfluxFlushInterval is the longest time a point waits in the batch
+	InfluxFlushInterval = time.Second
+)
+
+var (
+	ErrInvalidInfluxAdapterConfiguration = errors.New("[Influx] invalid adapter configuration")
+	ErrInfluxWrite                       = errors.New("[Influx] write failed")
+
+	// lineProtocolEscaper escapes the tag keys, the tag values and the field keys
+	lineProtocolEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	// measurementEscaper escapes the measurement, where the equal sign is allowed
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+)
+
+// InfluxConverter writes the data to InfluxDB 2 as the line protocol points, batched by InfluxBatchSize points.
+// The measurement is the game, the car, the track, the source and the driver are the tags
+// and every channel is a field.
+type InfluxConverter struct {
+	ConverterData
+	URL, Org, Bucket, Token string
+	BatchSize               int
+	FlushInterval           time.Duration
+	client                  *http.Client
+	userId                  string
+	drivers                 Drivers
+	mu                      sync.Mutex
+	batch                   bytes.Buffer
+	points                  int
+}
+
+// NewInfluxConverter creates a new InfluxConverter from the `influx:url:org:bucket:token` configuration,
+// the URL contains colons so the org, the bucket and the token are the last parts
+func NewInfluxConverter(game enums.Game, adapterConfiguration []string) (*InfluxConverter, error) {
+	if len(adapterConfiguration) < 5 {
+		return nil, ErrInvalidInfluxAdapterConfiguration
+	}
+	parts := len(adapterConfiguration)
+	serverURL := strings.Join(adapterConfiguration[1:parts-3], ":")
+	if _, err := url.ParseRequestURI(serverURL); err != nil {
+		return nil, errors.Wrapf(ErrInvalidInfluxAdapterConfiguration, "[%s] %v", game, err)
+	}
+	drivers, err := ParseDrivers(os.Getenv(DriversEnvKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return &InfluxConverter{
+		ConverterData: ConverterData{GameName: game},
+		URL:           strings.TrimRight(serverURL, "/"),
+		Org:           adapterConfiguration[parts-3],
+		Bucket:        adapterConfiguration[parts-2],
+		Token:         adapterConfiguration[parts-1],
+		BatchSize:     InfluxBatchSize,
+		FlushInterval: InfluxFlushInterval,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		userId:        os.Getenv("USER_ID"),
+		drivers:       drivers,
+	}, nil
+}
+
+// ChannelInit converts the data until ctx is cancelled and writes the batch every FlushInterval,
+// so the points are written when the game stops sending
+func (influx *InfluxConverter) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("InfluxConverter ChannelInit")
+	flushed := flushPeriodically(ctx, influx.FlushInterval, influx.Flush)
+
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		influx.Convert(now, data, port)
+		data.Release()
+	})
+	<-flushed
+}
+
+// Convert adds the data to the batch, the batch is written once it has BatchSize points
+func (influx *InfluxConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	if data.Normalized == nil || !data.Normalized.IsRaceOn {
+		return
+	}
+
+	influx.mu.Lock()
+	influx.appendPoint(data, time.Now())
+	full := influx.points >= influx.BatchSize
+	influx.mu.Unlock()
+
+	if !full {
+		return
+	}
+	if err := influx.Flush(); err != nil {
+		log.Println(err)
+	}
+}
+
+// appendPoint writes the data as a line protocol point to the batch
+func (influx *InfluxConverter) appendPoint(data telemetry.GameData, timestamp time.Time) {
+	line := &influx.batch
+	start := line.Len()
+
+	line.WriteString(measurementEscaper.Replace(string(influx.GameName)))
+	line.WriteString(",car=")
+	line.WriteString(strconv.Itoa(data.Normalized.CarID))
+	line.WriteString(",track=")
+	line.WriteString(strconv.Itoa(data.Normalized.TrackID))
+	if data.Source.IsValid() {
+		line.WriteString(",source=")
+		line.WriteString(lineProtocolEscaper.Replace(data.Source.String()))
+	}
+	if driver := influx.drivers.UserID(data.Source, influx.userId); driver != "" {
+		line.WriteString(",driver=")
+		line.WriteString(lineProtocolEscaper.Replace(driver))
+	}
+
+	separator := byte(' ')
+	for _, key := range data.Keys {
+		field, ok := influxField(data, key)
+		if !ok {
+			continue
+		}
+		line.WriteByte(separator)
+		line.WriteString(lineProtocolEscaper.Replace(key))
+		line.WriteByte('=')
+		line.WriteString(field)
+		separator = ','
+	}
+	if separator == ' ' {
+		// a point without fields is rejected by the server
+		line.Truncate(start)
+		return
+	}
+
+	line.WriteByte(' ')
+	line.WriteString(strconv.FormatInt(timestamp.UnixNano(), 10))
+	line.WriteByte('\n')
+	influx.points++
+}
+
+// influxField formats the value of the key, the integers with the i suffix.
+// The missing values and the NaN and infinite floats are not supported by the line protocol.
+func influxField(data telemetry.GameData, key string) (string, bool) {
+	if value, ok := data.Values[key]; ok {
+		if !value.IsFloat() {
+			return strconv.FormatInt(value.Int, 10) + "i", true
+		}
+		if math.IsNaN(value.Float) || math.IsInf(value.Float, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(value.Float, 'g', -1, 64), true
+	}
+
+	value, ok := data.Data[key]
+	if !ok || math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+		return "", false
+	}
+	return strconv.FormatFloat(float64(value), 'g', -1, 32), true
+}
+
+// Flush writes the batch to the bucket, the batch is dropped when the server rejects it
+func (influx *InfluxConverter) Flush() error {
+	influx.mu.Lock()
+	if influx.points == 0 {
+		influx.mu.Unlock()
+		return nil
+	}
+	body := bytes.Clone(influx.batch.Bytes())
+	points := influx.points
+	influx.batch.Reset()
+	influx.points = 0
+	influx.mu.Unlock()
+
+	query := url.Values{"org": {influx.Org}, "bucket": {influx.Bucket}, "precision": {"ns"}}
+	request, err := http.NewRequest(http.MethodPost, influx.URL+"/api/v2/write?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Token "+influx.Token)
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	response, err := influx.client.Do(request)
+	if err != nil {
+		return errors.Wrapf(ErrInfluxWrite, "%d points: %v", points, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Wrapf(ErrInfluxWrite, "%d points: %s %s", points, response.Status, message)
+	}
+	telemetry.DisplayLog("vvv", fmt.Sprintf("[Influx] %d points written", points))
+	return nil
+}
+
+// Close writes the rest of the batch
+func (influx *InfluxConverter) Close() error {
+	return influx.Flush()
+}
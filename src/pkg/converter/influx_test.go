@@ -0,0 +1,120 @@
+package converter_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInfluxConverter(t *testing.T) {
+	influx, err := converter.NewInfluxConverter(
+		enums.Games.F1(), strings.Split("influx:http://influxdb:8086/:home:telemetry:secret-token", ":"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "http://influxdb:8086", influx.URL)
+	assert.Equal(t, "home", influx.Org)
+	assert.Equal(t, "telemetry", influx.Bucket)
+	assert.Equal(t, "secret-token", influx.Token)
+
+	for _, configuration := range []string{"influx:home:telemetry:token", "influx:influxdb:home:telemetry:token"} {
+		_, err = converter.NewInfluxConverter(enums.Games.F1(), strings.Split(configuration, ":"))
+		assert.ErrorIs(t, err, converter.ErrInvalidInfluxAdapterConfiguration, configuration)
+	}
+}
+
+func TestInfluxConverter_Convert(t *testing.T) {
+	t.Setenv(converter.DriversEnvKey, "192.168.1.10=alice")
+	t.Setenv("USER_ID", "")
+	influxDB := &fakeInfluxDB{}
+	server := httptest.NewServer(influxDB)
+	defer server.Close()
+
+	influx, err := converter.NewInfluxConverter(
+		enums.Games.ForzaMotorsport2023(), strings.Split("influx:"+server.URL+":home:telemetry:secret-token", ":"),
+	)
+	require.NoError(t, err)
+	influx.BatchSize = 2
+
+	data := telemetry.GameData{
+		Keys: []string{"IsRaceOn", "Speed", "Gear", "Missing"},
+		Data: map[string]float32{"IsRaceOn": 1, "Speed": 42.5, "Gear": 3},
+		Values: map[string]telemetry.Value{
+			"IsRaceOn": {DataType: "S32", Int: 1},
+			"Gear":     {DataType: "U8", Int: 3},
+		},
+		Normalized: &telemetry.Normalized{IsRaceOn: true, CarID: 2345, TrackID: 11},
+		Source:     netip.MustParseAddrPort("192.168.1.10:50001"),
+	}
+	influx.Convert(time.Now(), data, 9999)
+	// the data outside the race is not written
+	influx.Convert(time.Now(), telemetry.GameData{Normalized: &telemetry.Normalized{}}, 9999)
+	assert.Empty(t, influxDB.all(), "the batch is not full")
+
+	data.Source = netip.AddrPort{}
+	influx.Convert(time.Now(), data, 9999)
+	requests := influxDB.all()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/api/v2/write?bucket=telemetry&org=home&precision=ns", requests[0].url)
+	assert.Equal(t, "Token secret-token", requests[0].authorization)
+
+	lines := strings.Split(strings.TrimSuffix(requests[0].body, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^fms2023,car=2345,track=11,source=192\.168\.1\.10:50001,driver=alice `+
+		`IsRaceOn=1i,Speed=42\.5,Gear=3i \d+$`, lines[0])
+	assert.Regexp(t, `^fms2023,car=2345,track=11 IsRaceOn=1i,Speed=42\.5,Gear=3i \d+$`, lines[1])
+
+	// the rest of the batch is written on close
+	influx.Convert(time.Now(), data, 9999)
+	require.NoError(t, influx.Close())
+	assert.Len(t, influxDB.all(), 2)
+	require.NoError(t, influx.Close(), "nothing is written without points")
+	assert.Len(t, influxDB.all(), 2)
+
+	influxDB.status = http.StatusUnauthorized
+	influx.Convert(time.Now(), data, 9999)
+	assert.ErrorIs(t, influx.Flush(), converter.ErrInfluxWrite)
+}
+
+type influxRequest struct {
+	url, authorization, body string
+}
+
+// fakeInfluxDB records the write requests
+type fakeInfluxDB struct {
+	mu       sync.Mutex
+	requests []influxRequest
+	status   int
+}
+
+func (f *fakeInfluxDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, influxRequest{
+		url:           r.URL.String(),
+		authorization: r.Header.Get("Authorization"),
+		body:          string(body),
+	})
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) all() []influxRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]influxRequest{}, f.requests...)
+}
@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -37,20 +38,17 @@ func NewMySQLConverter(game enums.Game, adapterConfiguration []string) (*MySQLCo
 	}, nil
 }
 
-func (db *MySQLConverter) ChannelInit(now time.Time, channel chan telemetry.GameData, port int) {
+func (db *MySQLConverter) ChannelInit(ctx context.Context, now time.Time, channel chan telemetry.GameData, port int) {
 	fmt.Println("MySQLConverter ChannelInit")
-	//nolint:gosimple // loop is needed to keep the channel open
-	for {
-		select {
-		case data := <-channel:
-			db.Convert(now, data, port)
-		}
-	}
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		db.Convert(now, data, port)
+		data.Release()
+	})
 }
 
 // Convert converts the data to the MySQL database
 func (db *MySQLConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
-	if data.Data["IsRaceOn"] == 0 {
+	if data.Normalized == nil || !data.Normalized.IsRaceOn {
 		return
 	}
 
@@ -72,7 +70,7 @@ func (db *MySQLConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
 
 	values := make([]interface{}, len(data.Keys))
 	for i, key := range data.Keys {
-		values[i] = data.Data[key]
+		values[i] = data.Native(key)
 	}
 
 	queryInsertBuilder := sq.Insert(db.TableName).Columns(data.Keys...).Values(values...)
@@ -88,3 +86,13 @@ func (db *MySQLConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
 		return
 	}
 }
+
+// Close closes the database connection
+func (db *MySQLConverter) Close() error {
+	if db.connector == nil {
+		return nil
+	}
+	err := db.connector.Close()
+	db.connector = nil
+	return err
+}
@@ -1,9 +1,12 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
+	"strconv"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -13,16 +16,22 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
-	"golang.org/x/sync/semaphore"
 )
 
-var semInsert = semaphore.NewWeighted(1)
+// BestLapsTableName is the table of the best laps of every game, the game is stored in its game column
+const BestLapsTableName = "tmd_forzamotorsport2023_bestlaps"
 
 type MysqlBestLapConverter struct {
 	ConverterData
 	User, Password, Host, Port, Database, TableName string
 	connector                                       *sqlx.DB
 	userId                                          string
+	// DriverName is the database/sql driver the connection is opened with
+	DriverName string
+	// drivers maps the game sending the data to the driver, userId is used for the unknown games
+	drivers Drivers
+	// lastValueCache contains the last inserted best lap by the game sending the data, so the same lap is inserted once
+	lastValueCache map[netip.AddrPort]hashCache
 }
 
 type dbData struct {
@@ -33,6 +42,7 @@ type dbData struct {
 type BestLapEntity struct {
 	ID                  int64     `db:"id"`
 	UserID              int64     `db:"user_id"`
+	Game                string    `db:"game"`
 	CarOrdinal          int       `db:"CarOrdinal"`
 	TrackOrdinal        int       `db:"TrackOrdinal"`
 	BestLap             float32   `db:"BestLap"`
@@ -48,44 +58,49 @@ type BestLapEntity struct {
 
 type hashCache string
 
-var lastValueCache map[int]hashCache
-
 func NewMysqlBestLapConverter(game enums.Game, adapterConfiguration []string) (*MysqlBestLapConverter, error) {
 	if len(adapterConfiguration) != 6 {
 		return nil, ErrInvalidMySQLAdapterConfiguration
 	}
-	lastValueCache = make(map[int]hashCache)
+	drivers, err := ParseDrivers(os.Getenv(DriversEnvKey))
+	if err != nil {
+		return nil, err
+	}
 
 	return &MysqlBestLapConverter{
-		ConverterData: ConverterData{GameName: game},
-		User:          adapterConfiguration[1],
-		Password:      adapterConfiguration[2],
-		Host:          adapterConfiguration[3],
-		Port:          adapterConfiguration[4],
-		Database:      adapterConfiguration[5],
-		TableName:     "tmd_forzamotorsport2023_bestlaps",
-		userId:        os.Getenv("USER_ID"),
+		ConverterData:  ConverterData{GameName: game},
+		User:           adapterConfiguration[1],
+		Password:       adapterConfiguration[2],
+		Host:           adapterConfiguration[3],
+		Port:           adapterConfiguration[4],
+		Database:       adapterConfiguration[5],
+		TableName:      BestLapsTableName,
+		DriverName:     "mysql",
+		userId:         os.Getenv("USER_ID"),
+		drivers:        drivers,
+		lastValueCache: map[netip.AddrPort]hashCache{},
 	}, nil
 }
 
-func (db *MysqlBestLapConverter) ChannelInit(now time.Time, channel chan telemetry.GameData, port int) {
+func (db *MysqlBestLapConverter) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
 	fmt.Println("MysqlBestLapConverter ChannelInit")
-	//nolint:gosimple // loop is needed to keep the channel open
-	for {
-		select {
-		case data := <-channel:
-			db.Convert(now, data, port)
-		}
-	}
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		db.Convert(now, data, port)
+		data.Release()
+	})
 }
 
 // Convert converts the data to the MySQL database
-func (db *MysqlBestLapConverter) Convert(_ time.Time, data telemetry.GameData, port int) {
+// The best laps of every game sending to the port are stored separately, with the user ID of its driver.
+// Only the laps of the player car are stored, the games sending every car send the laps of the other cars too.
+func (db *MysqlBestLapConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
 	if db.connector == nil {
 		fmt.Println("Reconnecting to MySQL BL...")
 		var err error
 		db.connector, err = sqlx.Open(
-			"mysql",
+			db.DriverName,
 			fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", db.User, db.Password, db.Host, db.Port, db.Database),
 		)
 		if err != nil {
@@ -98,37 +113,36 @@ func (db *MysqlBestLapConverter) Convert(_ time.Time, data telemetry.GameData, p
 		fmt.Println("Reconnecting to MySQL BL... Connected")
 	}
 
-	if data.Data["LastLap"] == 0 {
+	sample := data.Normalized
+	if sample == nil || sample.LastLap == 0 || !sample.IsPlayer {
 		return
 	}
 
+	userID := db.drivers.UserID(data.Source, db.userId)
 	isBestLap, cacheHash := db.bestLapExists(
-		port,
-		data.Data["TrackOrdinal"],
-		data.Data["LapNumber"],
+		data.Source,
+		userID,
+		float32(sample.TrackID),
+		float32(sample.LapNumber),
 	)
-	if !isBestLap || !semInsert.TryAcquire(1) {
+	if !isBestLap {
 		return
 	}
-	defer semInsert.Release(1)
 
 	myData := dbData{
 		Keys: []string{
-			"CarOrdinal", "CarClass", "CarPerformanceIndex", "DrivetrainType", "NumCylinders",
-			"Fuel", "BestLap", "LapNumber", "RacePosition", "TrackOrdinal", "user_id",
+			"CarOrdinal", "CarClass", "Fuel", "BestLap", "LapNumber", "RacePosition", "TrackOrdinal", "user_id", "game",
 		},
 		Values: []string{
-			fmt.Sprintf("%f", data.Data["CarOrdinal"]),
-			fmt.Sprintf("%f", data.Data["CarClass"]),
-			fmt.Sprintf("%f", data.Data["CarPerformanceIndex"]),
-			fmt.Sprintf("%f", data.Data["DrivetrainType"]),
-			fmt.Sprintf("%f", data.Data["NumCylinders"]),
-			fmt.Sprintf("%f", data.Data["Fuel"]),
-			fmt.Sprintf("%f", data.Data["LastLap"]),
-			fmt.Sprintf("%f", data.Data["LapNumber"]),
-			fmt.Sprintf("%f", data.Data["RacePosition"]),
-			fmt.Sprintf("%f", data.Data["TrackOrdinal"]),
-			db.userId,
+			strconv.Itoa(sample.CarID),
+			strconv.Itoa(sample.CarClass),
+			fmt.Sprintf("%f", sample.Fuel),
+			fmt.Sprintf("%f", sample.LastLap),
+			strconv.Itoa(sample.LapNumber),
+			strconv.Itoa(sample.RacePosition),
+			strconv.Itoa(sample.TrackID),
+			userID,
+			string(db.GameName),
 		},
 	}
 
@@ -151,7 +165,7 @@ func (db *MysqlBestLapConverter) Convert(_ time.Time, data telemetry.GameData, p
 	if errors.As(err, &mysqlError) {
 		if mysqlError.Number == 1062 {
 			// unique key. Skipping the insert and update the cache
-			lastValueCache[port] = cacheHash
+			db.lastValueCache[data.Source] = cacheHash
 			return
 		}
 	}
@@ -160,7 +174,17 @@ func (db *MysqlBestLapConverter) Convert(_ time.Time, data telemetry.GameData, p
 		return
 	}
 
-	lastValueCache[port] = cacheHash
+	db.lastValueCache[data.Source] = cacheHash
+}
+
+// Close closes the database connection
+func (db *MysqlBestLapConverter) Close() error {
+	if db.connector == nil {
+		return nil
+	}
+	err := db.connector.Close()
+	db.connector = nil
+	return err
 }
 
 func (db *MysqlBestLapConverter) getHashCacheString(
@@ -170,10 +194,14 @@ func (db *MysqlBestLapConverter) getHashCacheString(
 	return hashCache(fmt.Sprintf("%s-%f-%f", userID, trackOrdinal, lapNumber))
 }
 
-func (db *MysqlBestLapConverter) bestLapExists(port int, trackOrdinal, lapNumber float32) (bool, hashCache) {
-	hash := db.getHashCacheString(db.userId, trackOrdinal, lapNumber)
+func (db *MysqlBestLapConverter) bestLapExists(
+	source netip.AddrPort,
+	userID string,
+	trackOrdinal, lapNumber float32,
+) (bool, hashCache) {
+	hash := db.getHashCacheString(userID, trackOrdinal, lapNumber)
 
-	if lastValueCache[port] == "" || lastValueCache[port] != hash {
+	if db.lastValueCache[source] == "" || db.lastValueCache[source] != hash {
 		return true, hash
 	}
 	return false, hash
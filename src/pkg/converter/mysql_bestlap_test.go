@@ -0,0 +1,125 @@
+package converter_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDriverName is the database/sql driver recording the statements instead of running them
+const recordingDriverName = "tmd_recording"
+
+// recordedStatements contains the arguments of the executed statements by the data source name
+var recordedStatements = struct {
+	mu    sync.Mutex
+	byDSN map[string][][]driver.Value
+}{byDSN: map[string][][]driver.Value{}}
+
+type recordingDriver struct{}
+
+type recordingConn struct {
+	dsn string
+}
+
+func init() {
+	sql.Register(recordingDriverName, recordingDriver{})
+}
+
+func (recordingDriver) Open(dsn string) (driver.Conn, error) {
+	return &recordingConn{dsn: dsn}, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	recordedStatements.mu.Lock()
+	recordedStatements.byDSN[c.dsn] = append(recordedStatements.byDSN[c.dsn], values)
+	recordedStatements.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("the prepared statements are not supported")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("the transactions are not supported")
+}
+
+// newRecordingBestLapConverter creates the adapter writing to the recording driver,
+// the statements are recorded by the database name
+func newRecordingBestLapConverter(t *testing.T, game enums.Game, database string) *converter.MysqlBestLapConverter {
+	bestLap, err := converter.NewMysqlBestLapConverter(
+		game, []string{"mysql_bl", "user", "pass", "db", "3306", database},
+	)
+	require.NoError(t, err)
+	bestLap.DriverName = recordingDriverName
+	t.Cleanup(func() { _ = bestLap.Close() })
+	return bestLap
+}
+
+// recordedBestLaps returns the recorded inserts of the database
+func recordedBestLaps(database string) [][]driver.Value {
+	recordedStatements.mu.Lock()
+	defer recordedStatements.mu.Unlock()
+	return recordedStatements.byDSN["user:pass@tcp(db:3306)/"+database]
+}
+
+func TestMysqlBestLapConverter_ConvertPlayerOnly(t *testing.T) {
+	t.Setenv("USER_ID", "2")
+	bestLap := newRecordingBestLapConverter(t, enums.Games.AssettoCorsaCompetizione(), t.Name())
+
+	// a realtime update of ACC is published for every car, the focused car is the player
+	source := netip.MustParseAddrPort("192.168.1.10:9000")
+	for _, car := range []telemetry.Normalized{
+		{IsRaceOn: true, CarID: 7, TrackID: 3, LapNumber: 4, LastLap: 101.5, RacePosition: 2},
+		{IsRaceOn: true, IsPlayer: true, CarID: 12, TrackID: 3, LapNumber: 4, LastLap: 99.25, RacePosition: 1},
+		{IsRaceOn: true, CarID: 31, TrackID: 3, LapNumber: 3, LastLap: 103, RacePosition: 3},
+	} {
+		bestLap.Convert(time.Now(), telemetry.GameData{Normalized: &car, Source: source}, 9000)
+	}
+
+	laps := recordedBestLaps(t.Name())
+	require.Len(t, laps, 1)
+	// the values in the order of the columns, CarOrdinal first and the user ID and the game last
+	assert.Equal(t, "12", laps[0][0])
+	assert.Equal(t, "99.250000", laps[0][3])
+	assert.Equal(t, "2", laps[0][7])
+	assert.Equal(t, string(enums.Games.AssettoCorsaCompetizione()), laps[0][8])
+}
+
+func TestMysqlBestLapConverter_ConvertInstancesCache(t *testing.T) {
+	first := newRecordingBestLapConverter(t, enums.Games.ForzaMotorsport2023(), t.Name()+"-first")
+	second := newRecordingBestLapConverter(t, enums.Games.ForzaMotorsport2023(), t.Name()+"-second")
+
+	lap := telemetry.GameData{
+		Normalized: &telemetry.Normalized{IsRaceOn: true, IsPlayer: true, TrackID: 11, LapNumber: 1, LastLap: 95.5},
+		Source:     netip.MustParseAddrPort("192.168.1.10:50001"),
+	}
+	// the lap inserted by the first adapter is not cached for the second one
+	first.Convert(time.Now(), lap, 9999)
+	second.Convert(time.Now(), lap, 9999)
+	// the lap is cached by every adapter
+	first.Convert(time.Now(), lap, 9999)
+	second.Convert(time.Now(), lap, 9999)
+
+	assert.Len(t, recordedBestLaps(t.Name()+"-first"), 1)
+	assert.Len(t, recordedBestLaps(t.Name()+"-second"), 1)
+}
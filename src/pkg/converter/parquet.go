@@ -0,0 +1,292 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/parquet-go/parquet-go"
+	"github.com/spf13/afero"
+)
+
+const (
+	// ParquetRowGroupRows is the number of rows buffered in memory before the row group is written to the file
+	ParquetRowGroupRows = 10000
+	// ParquetFlushInterval is the longest time a row is buffered before its row group is written
+	ParquetFlushInterval = 30 * time.Second
+	// parquetExtension is the extension of the files, the retention paths end with it
+	parquetExtension = ".parquet"
+)
+
+var ErrInvalidParquetAdapterConfiguration = errors.New("[Parquet] invalid adapter configuration")
+
+// ParquetConverter writes the data to the Parquet files with the same retention as the CSV adapter.
+// The columns are typed by the data type of the channels, the file has the time and the source columns too.
+// A Parquet file can not be appended, so the file is readable once it is closed: when the retention switches
+// to a new file or the app stops. The existing file is kept and the rows are written to a new part of it.
+type ParquetConverter struct {
+	ConverterData
+	Fs            afero.Fs
+	FilePath      string
+	Retention     enums.RetentionType
+	RowGroupRows  int
+	FlushInterval time.Duration
+
+	mu sync.Mutex
+	// retentionPath is the file path of the retention, the open file is its part when the file existed
+	retentionPath string
+	file          afero.File
+	writer        *parquet.Writer
+	// columns contains the channels of the file schema, the schema is made of the first row of the file
+	columns        map[string]parquetColumn
+	timeColumn     int
+	sourceColumn   int
+	row            parquet.Row
+	buffered       int
+	missingColumns map[string]bool
+}
+
+type parquetColumn struct {
+	index    int
+	dataType string
+}
+
+// NewParquetConverter creates a new ParquetConverter from the `parquet:path:retention` configuration
+func NewParquetConverter(game enums.Game, adapterConfiguration []string, fs afero.Fs) (*ParquetConverter, error) {
+	if len(adapterConfiguration) != 3 {
+		return nil, ErrInvalidParquetAdapterConfiguration
+	}
+
+	return &ParquetConverter{
+		ConverterData: ConverterData{GameName: game},
+		Fs:            fs,
+		FilePath:      adapterConfiguration[1],
+		Retention:     enums.RetentionType(adapterConfiguration[2]),
+		RowGroupRows:  ParquetRowGroupRows,
+		FlushInterval: ParquetFlushInterval,
+	}, nil
+}
+
+// ChannelInit converts the data until ctx is cancelled and writes the row group every FlushInterval,
+// the data is converted at the time it is received so the daily retention switches the file at midnight
+func (p *ParquetConverter) ChannelInit(
+	ctx context.Context, _ time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("ParquetConverter ChannelInit")
+	flushed := flushPeriodically(ctx, p.FlushInterval, p.Flush)
+
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		p.Convert(time.Now(), data, port)
+		data.Release()
+	})
+	<-flushed
+}
+
+// Convert adds the data as a row to the file of the retention, the row group is written once it has
+// RowGroupRows rows. The channels which are not in the file schema are skipped.
+func (p *ParquetConverter) Convert(now time.Time, data telemetry.GameData, _ int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	afs := &afero.Afero{Fs: p.Fs}
+	filePath, err := retentionFilePath(afs, p.GameName, p.FilePath, p.Retention, parquetExtension, now)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if p.writer == nil || p.retentionPath != filePath {
+		// the retention has switched to a new file
+		if err = p.closeFile(); err != nil {
+			log.Println(err)
+		}
+		if err = p.openFile(filePath, data); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+
+	for i := range p.row {
+		p.row[i] = parquet.NullValue().Level(0, 0, i)
+	}
+	p.row[p.timeColumn] = parquet.Int64Value(now.UnixMilli()).Level(0, 0, p.timeColumn)
+	if data.Source.IsValid() {
+		p.row[p.sourceColumn] = parquet.ByteArrayValue([]byte(data.Source.String())).Level(0, 1, p.sourceColumn)
+	}
+	for _, key := range data.Keys {
+		column, ok := p.columns[key]
+		if !ok {
+			if !p.missingColumns[key] {
+				p.missingColumns[key] = true
+				log.Printf("[Parquet] %s is not in the schema of %s, it is skipped", key, p.file.Name())
+			}
+			continue
+		}
+		p.row[column.index] = parquetValue(data, key, column.dataType).Level(0, 1, column.index)
+	}
+
+	if _, err = p.writer.WriteRows([]parquet.Row{p.row}); err != nil {
+		log.Println(err)
+		return
+	}
+	p.buffered++
+	if p.buffered >= p.RowGroupRows {
+		if err = p.flush(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// openFile creates the file with the schema of the channels of the data,
+// the existing file is kept and a new part is created next to it, eg. fms2023.1.parquet
+func (p *ParquetConverter) openFile(filePath string, data telemetry.GameData) error {
+	afs := &afero.Afero{Fs: p.Fs}
+	name := filePath
+	for part := 1; ; part++ {
+		exists, err := afs.Exists(name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			break
+		}
+		name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(filePath, parquetExtension), part, parquetExtension)
+	}
+	file, err := afs.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	group := parquet.Group{
+		"time":   parquet.Timestamp(parquet.Millisecond),
+		"source": parquet.Optional(parquet.String()),
+	}
+	dataTypes := map[string]string{}
+	for _, key := range data.Keys {
+		if _, ok := group[key]; ok {
+			continue
+		}
+		dataTypes[key] = parquetDataType(data, key)
+		group[key] = parquet.Optional(parquetNode(dataTypes[key]))
+	}
+	schema := parquet.NewSchema(string(p.GameName), group)
+
+	p.columns = make(map[string]parquetColumn, len(dataTypes))
+	for key, dataType := range dataTypes {
+		leaf, _ := schema.Lookup(key)
+		p.columns[key] = parquetColumn{index: leaf.ColumnIndex, dataType: dataType}
+	}
+	timeLeaf, _ := schema.Lookup("time")
+	sourceLeaf, _ := schema.Lookup("source")
+	p.timeColumn, p.sourceColumn = timeLeaf.ColumnIndex, sourceLeaf.ColumnIndex
+
+	p.row = make(parquet.Row, len(schema.Columns()))
+	p.missingColumns = map[string]bool{}
+	p.file = file
+	p.retentionPath = filePath
+	p.writer = parquet.NewWriter(file, schema, parquet.Compression(&parquet.Snappy))
+	log.Printf("[Parquet] writing %d channels to %s", len(dataTypes), name)
+	return nil
+}
+
+// parquetDataType returns the data type of the channel, the channels without the native value are F32
+func parquetDataType(data telemetry.GameData, key string) string {
+	if value, ok := data.Values[key]; ok {
+		return value.DataType
+	}
+	return "F32"
+}
+
+// parquetNode returns the column type of the data type, the unsigned integers keep their logical type
+func parquetNode(dataType string) parquet.Node {
+	switch dataType {
+	case "F32":
+		return parquet.Leaf(parquet.FloatType)
+	case "F64":
+		return parquet.Leaf(parquet.DoubleType)
+	case "U8":
+		return parquet.Uint(8)
+	case "S8":
+		return parquet.Int(8)
+	case "U16":
+		return parquet.Uint(16)
+	case "S16":
+		return parquet.Int(16)
+	case "S32":
+		return parquet.Int(32)
+	default:
+		return parquet.Uint(32)
+	}
+}
+
+// parquetValue converts the value of the key to the data type of its column
+func parquetValue(data telemetry.GameData, key, dataType string) parquet.Value {
+	value, ok := data.Values[key]
+	if !ok {
+		value = telemetry.Value{DataType: "F32", Float: float64(data.Data[key])}
+	}
+
+	switch dataType {
+	case "F32":
+		return parquet.FloatValue(value.Float32())
+	case "F64":
+		if !value.IsFloat() {
+			return parquet.DoubleValue(float64(value.Int))
+		}
+		return parquet.DoubleValue(value.Float)
+	}
+	integer := value.Int
+	if value.IsFloat() {
+		integer = int64(value.Float)
+	}
+	if dataType == "U32" {
+		// the unsigned 32 bit integers are stored in the INT32 physical type
+		return parquet.Int32Value(int32(uint32(integer)))
+	}
+	return parquet.Int32Value(int32(integer))
+}
+
+// Flush writes the buffered rows to the file as a row group
+func (p *ParquetConverter) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.flush()
+}
+
+func (p *ParquetConverter) flush() error {
+	if p.writer == nil || p.buffered == 0 {
+		return nil
+	}
+	p.buffered = 0
+	if err := p.writer.Flush(); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+// Close writes the buffered rows and the footer and closes the current file
+func (p *ParquetConverter) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closeFile()
+}
+
+func (p *ParquetConverter) closeFile() error {
+	if p.writer == nil {
+		return nil
+	}
+	err := p.writer.Close()
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	p.writer, p.file, p.retentionPath, p.buffered = nil, nil, "", 0
+	return err
+}
@@ -0,0 +1,117 @@
+package converter_test
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/parquet-go/parquet-go"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parquetRow struct {
+	Time        time.Time `parquet:"time,timestamp(millisecond)"`
+	Source      *string   `parquet:"source,optional"`
+	TimestampMS *uint32   `parquet:"TimestampMS,optional"`
+	Speed       *float32  `parquet:"Speed,optional"`
+	Gear        *uint8    `parquet:"Gear,optional"`
+	Steer       *int8     `parquet:"Steer,optional"`
+}
+
+func TestNewParquetConverter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	p, err := converter.NewParquetConverter(enums.Games.F1(), []string{"parquet", "./data/f1", "daily"}, fs)
+	require.NoError(t, err)
+	assert.Equal(t, "./data/f1", p.FilePath)
+	assert.Equal(t, enums.RetentionTypes.Daily(), p.Retention)
+
+	_, err = converter.NewParquetConverter(enums.Games.F1(), []string{"parquet", "./data/f1"}, fs)
+	assert.ErrorIs(t, err, converter.ErrInvalidParquetAdapterConfiguration)
+}
+
+//nolint:errcheck
+func TestParquetConverter_Convert(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	fs.MkdirAll("/var/www/simracing-telemetry", 0o755)
+	p, err := converter.NewParquetConverter(
+		enums.Games.ForzaMotorsport2023(), []string{"parquet", "/var/www/simracing-telemetry", "daily"}, fs,
+	)
+	require.NoError(t, err)
+
+	data := telemetry.GameData{
+		Keys: []string{"TimestampMS", "Speed", "Gear", "Steer"},
+		Data: map[string]float32{"TimestampMS": 38404608, "Speed": 42.5, "Gear": 3, "Steer": -12},
+		Values: map[string]telemetry.Value{
+			"TimestampMS": {DataType: "U32", Int: 38404609},
+			"Gear":        {DataType: "U8", Int: 3},
+			"Steer":       {DataType: "S8", Int: -12},
+		},
+		Source: netip.MustParseAddrPort("192.168.1.10:50001"),
+	}
+	day := time.Date(2026, 10, 17, 23, 59, 59, 0, time.UTC)
+	p.Convert(day, data, 9999)
+	// the channels which are not in the schema are skipped and the missing ones are null
+	p.Convert(day, telemetry.GameData{
+		Keys: []string{"Speed", "Extra"},
+		Data: map[string]float32{"Speed": 10, "Extra": 1},
+	}, 9999)
+	require.NoError(t, p.Flush())
+	// the daily retention switches to a new file
+	p.Convert(day.Add(time.Second), data, 9999)
+	require.NoError(t, p.Close())
+
+	rows := readParquet(t, fs, "/var/www/simracing-telemetry/fms2023-daily-2026-10-17.parquet")
+	require.Len(t, rows, 2)
+	assert.Equal(t, day, rows[0].Time.UTC())
+	assert.Equal(t, "192.168.1.10:50001", *rows[0].Source)
+	assert.Equal(t, uint32(38404609), *rows[0].TimestampMS, "the integers are not stored as float32")
+	assert.Equal(t, float32(42.5), *rows[0].Speed)
+	assert.Equal(t, uint8(3), *rows[0].Gear)
+	assert.Equal(t, int8(-12), *rows[0].Steer)
+	assert.Equal(t, parquetRow{Time: day, Speed: ptr(float32(10))}, withUTC(rows[1]))
+
+	assert.Len(t, readParquet(t, fs, "/var/www/simracing-telemetry/fms2023-daily-2026-10-18.parquet"), 1)
+
+	// the existing file is not overwritten
+	p.Convert(day.Add(time.Second), data, 9999)
+	require.NoError(t, p.Close())
+	assert.Len(t, readParquet(t, fs, "/var/www/simracing-telemetry/fms2023-daily-2026-10-18.parquet"), 1)
+	assert.Len(t, readParquet(t, fs, "/var/www/simracing-telemetry/fms2023-daily-2026-10-18.1.parquet"), 1)
+	require.NoError(t, p.Close())
+}
+
+func readParquet(t *testing.T, fs afero.Fs, path string) []parquetRow {
+	file, err := fs.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	info, err := file.Stat()
+	require.NoError(t, err)
+
+	parquetFile, err := parquet.OpenFile(file, info.Size())
+	require.NoError(t, err)
+	for column, expected := range map[string]string{
+		"TimestampMS": "INT(32,false)", "Speed": "FLOAT", "Gear": "INT(8,false)", "Steer": "INT(8,true)",
+	} {
+		leaf, ok := parquetFile.Schema().Lookup(column)
+		require.True(t, ok, column)
+		assert.Equal(t, expected, leaf.Node.Type().String(), column)
+	}
+
+	rows, err := parquet.Read[parquetRow](file, info.Size())
+	require.NoError(t, err)
+	return rows
+}
+
+func withUTC(row parquetRow) parquetRow {
+	row.Time = row.Time.UTC()
+	return row
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,367 @@
+package converter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PostgresBatchSize is the number of rows ingested with a single COPY
+	PostgresBatchSize = 500
+	// PostgresFlushInterval is the longest time a row waits in the batch
+	PostgresFlushInterval = time.Second
+	// PostgresCompressAfter is the age of the TimescaleDB chunks compressed by the compression policy
+	PostgresCompressAfter = "7 days"
+)
+
+var (
+	ErrInvalidPostgresAdapterConfiguration = errors.New("[Postgres] invalid adapter configuration")
+	ErrPostgresWrite                       = errors.New("[Postgres] write failed")
+)
+
+// postgresFixedColumns are the columns of every row, followed by the channels of the game
+var postgresFixedColumns = []string{"time", "source", "driver"}
+
+// PostgresConverter writes the data to PostgreSQL with COPY, batched by PostgresBatchSize rows.
+// The table is created on the first write and the columns of the channels sent later are added to it,
+// with TimescaleDB the table is a compressed hypertable.
+type PostgresConverter struct {
+	ConverterData
+	User, Password, Host, Port, Database, TableName string
+	Timescale                                       bool
+	BatchSize                                       int
+	FlushInterval                                   time.Duration
+	connector                                       *sql.DB
+	userId                                          string
+	drivers                                         Drivers
+
+	mu sync.Mutex
+	// columns are the channels in the order they were first sent, columnIndex is the position in columns by the key
+	columns     []postgresColumn
+	columnIndex map[string]int
+	rows        [][]any
+
+	// writing serializes Flush, the schema state below is only used while writing
+	writing  sync.Mutex
+	created  bool
+	migrated int
+}
+
+type postgresColumn struct {
+	name, dataType string
+}
+
+// NewPostgresConverter creates a new PostgresConverter from the `postgres:user:password:host:port:database`
+// configuration, the `postgres:user:password:host:port:database:timescale` configuration enables TimescaleDB
+func NewPostgresConverter(game enums.Game, adapterConfiguration []string) (*PostgresConverter, error) {
+	if len(adapterConfiguration) != 6 && len(adapterConfiguration) != 7 {
+		return nil, ErrInvalidPostgresAdapterConfiguration
+	}
+	timescale := len(adapterConfiguration) == 7
+	if timescale && adapterConfiguration[6] != "timescale" {
+		return nil, errors.Wrapf(ErrInvalidPostgresAdapterConfiguration, "[%s] unknown option %s", game,
+			adapterConfiguration[6])
+	}
+	drivers, err := ParseDrivers(os.Getenv(DriversEnvKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresConverter{
+		ConverterData: ConverterData{GameName: game},
+		User:          adapterConfiguration[1],
+		Password:      adapterConfiguration[2],
+		Host:          adapterConfiguration[3],
+		Port:          adapterConfiguration[4],
+		Database:      adapterConfiguration[5],
+		TableName:     gameEnvKeys[game].DatabaseTable,
+		Timescale:     timescale,
+		BatchSize:     PostgresBatchSize,
+		FlushInterval: PostgresFlushInterval,
+		userId:        os.Getenv("USER_ID"),
+		drivers:       drivers,
+		columnIndex:   map[string]int{},
+	}, nil
+}
+
+// ChannelInit converts the data until ctx is cancelled and writes the batch every FlushInterval
+func (pg *PostgresConverter) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("PostgresConverter ChannelInit")
+	flushed := flushPeriodically(ctx, pg.FlushInterval, pg.Flush)
+
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		pg.Convert(now, data, port)
+		data.Release()
+	})
+	<-flushed
+}
+
+// Convert adds the data to the batch as a row, the batch is written once it has BatchSize rows
+func (pg *PostgresConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	if data.Normalized == nil || !data.Normalized.IsRaceOn {
+		return
+	}
+
+	row := make([]any, len(postgresFixedColumns), len(postgresFixedColumns)+len(data.Keys))
+	row[0] = time.Now()
+	if data.Source.IsValid() {
+		row[1] = data.Source.String()
+	}
+	if driver := pg.drivers.UserID(data.Source, pg.userId); driver != "" {
+		row[2] = driver
+	}
+
+	pg.mu.Lock()
+	for _, key := range data.Keys {
+		index, ok := pg.columnIndex[key]
+		if !ok {
+			index = len(pg.columns)
+			pg.columnIndex[key] = index
+			pg.columns = append(pg.columns, postgresColumn{name: key, dataType: postgresType(data, key)})
+		}
+		for len(row) <= len(postgresFixedColumns)+index {
+			row = append(row, nil)
+		}
+		row[len(postgresFixedColumns)+index] = postgresValue(data, key)
+	}
+	pg.rows = append(pg.rows, row)
+	full := len(pg.rows) >= pg.BatchSize
+	pg.mu.Unlock()
+
+	if !full {
+		return
+	}
+	if err := pg.Flush(); err != nil {
+		log.Println(err)
+	}
+}
+
+// postgresType returns the column type of the key, the channels without the native value are stored as real
+func postgresType(data telemetry.GameData, key string) string {
+	value, ok := data.Values[key]
+	if !ok {
+		return "real"
+	}
+	switch value.DataType {
+	case "F32":
+		return "real"
+	case "F64":
+		return "double precision"
+	case "U8", "S8", "S16":
+		return "smallint"
+	case "U16", "S32":
+		return "integer"
+	default:
+		return "bigint"
+	}
+}
+
+// postgresValue returns the native value of the key, the infinite floats are stored as NULL
+// as their text form is rejected by COPY
+func postgresValue(data telemetry.GameData, key string) any {
+	native := data.Native(key)
+	switch value := native.(type) {
+	case float32:
+		if math.IsInf(float64(value), 0) {
+			return nil
+		}
+	case float64:
+		if math.IsInf(value, 0) {
+			return nil
+		}
+	}
+	return native
+}
+
+// Flush creates or migrates the table and copies the batch to it, the batch is dropped when the copy fails
+func (pg *PostgresConverter) Flush() error {
+	pg.writing.Lock()
+	defer pg.writing.Unlock()
+
+	pg.mu.Lock()
+	rows := pg.rows
+	columns := append([]postgresColumn(nil), pg.columns...)
+	pg.rows = nil
+	pg.mu.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if pg.connector == nil {
+		log.Printf("[%s] connecting to PostgreSQL", pg.GameName)
+		connector, err := sql.Open("postgres", pg.dataSourceName())
+		if err != nil {
+			return errors.Wrapf(ErrPostgresWrite, "%d rows: %v", len(rows), err)
+		}
+		connector.SetConnMaxLifetime(time.Minute * 5)
+		connector.SetMaxOpenConns(2)
+		pg.connector = connector
+	}
+	if err := pg.migrate(columns); err != nil {
+		return errors.Wrapf(ErrPostgresWrite, "%d rows: %v", len(rows), err)
+	}
+	if err := pg.copyRows(columns, rows); err != nil {
+		return errors.Wrapf(ErrPostgresWrite, "%d rows: %v", len(rows), err)
+	}
+	telemetry.DisplayLog("vvv", fmt.Sprintf("[Postgres] %d rows written", len(rows)))
+	return nil
+}
+
+// dataSourceName returns the connection URL, TLS is disabled unless it is configured with PGSSLMODE
+func (pg *PostgresConverter) dataSourceName() string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(pg.User, pg.Password),
+		Host:   net.JoinHostPort(pg.Host, pg.Port),
+		Path:   "/" + pg.Database,
+	}
+	if os.Getenv("PGSSLMODE") == "" {
+		dsn.RawQuery = "sslmode=disable"
+	}
+	return dsn.String()
+}
+
+// migrate creates the table on the first write and adds the columns of the channels it does not have yet,
+// so the table created by an older version gets the new channels
+func (pg *PostgresConverter) migrate(columns []postgresColumn) error {
+	table := pq.QuoteIdentifier(pg.TableName)
+	if !pg.created {
+		definitions := []string{`"time" timestamptz NOT NULL`, `"source" text`, `"driver" text`}
+		for _, column := range columns {
+			definitions = append(definitions, pq.QuoteIdentifier(column.name)+" "+column.dataType)
+		}
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, strings.Join(definitions, ", ")),
+			fmt.Sprintf(
+				"CREATE INDEX IF NOT EXISTS %s ON %s (source, time DESC)",
+				pq.QuoteIdentifier(pg.TableName+"_source_time_idx"), table,
+			),
+		}
+		for _, statement := range statements {
+			if _, err := pg.connector.Exec(statement); err != nil {
+				return err
+			}
+		}
+		if pg.Timescale {
+			if err := pg.createHypertable(); err != nil {
+				return err
+			}
+		}
+		pg.created = true
+	}
+
+	if pg.migrated == len(columns) {
+		return nil
+	}
+	additions := make([]string, 0, len(columns)-pg.migrated)
+	for _, column := range columns[pg.migrated:] {
+		additions = append(additions, "ADD COLUMN IF NOT EXISTS "+pq.QuoteIdentifier(column.name)+" "+column.dataType)
+	}
+	if _, err := pg.connector.Exec(fmt.Sprintf("ALTER TABLE %s %s", table, strings.Join(additions, ", "))); err != nil {
+		return err
+	}
+	pg.migrated = len(columns)
+	return nil
+}
+
+// createHypertable turns the table into a hypertable partitioned by the time,
+// the compression is enabled once as the compressed hypertable settings can not be changed
+func (pg *PostgresConverter) createHypertable() error {
+	_, err := pg.connector.Exec(
+		"SELECT create_hypertable($1::regclass, 'time', if_not_exists => TRUE, migrate_data => TRUE)",
+		pg.TableName,
+	)
+	if err != nil {
+		return err
+	}
+
+	var compressed bool
+	err = pg.connector.QueryRow(
+		"SELECT compression_enabled FROM timescaledb_information.hypertables WHERE hypertable_name = $1",
+		pg.TableName,
+	).Scan(&compressed)
+	if err != nil || compressed {
+		return err
+	}
+	_, err = pg.connector.Exec(fmt.Sprintf(
+		"ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = 'source', "+
+			"timescaledb.compress_orderby = 'time DESC')",
+		pq.QuoteIdentifier(pg.TableName),
+	))
+	if err != nil {
+		return err
+	}
+	_, err = pg.connector.Exec(
+		"SELECT add_compression_policy($1::regclass, INTERVAL '"+PostgresCompressAfter+"', if_not_exists => TRUE)",
+		pg.TableName,
+	)
+	return err
+}
+
+// copyRows copies the rows in a transaction, the rows added before a channel was first sent are shorter
+func (pg *PostgresConverter) copyRows(columns []postgresColumn, rows [][]any) error {
+	names := append([]string(nil), postgresFixedColumns...)
+	for _, column := range columns {
+		names = append(names, column.name)
+	}
+
+	tx, err := pg.connector.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	statement, err := tx.Prepare(pq.CopyIn(pg.TableName, names...))
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(names))
+	for _, row := range rows {
+		clear(values)
+		copy(values, row)
+		if _, err = statement.Exec(values...); err != nil {
+			_ = statement.Close()
+			return err
+		}
+	}
+	if _, err = statement.Exec(); err != nil {
+		_ = statement.Close()
+		return err
+	}
+	if err = statement.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close writes the rest of the batch and closes the database connection
+func (pg *PostgresConverter) Close() error {
+	err := pg.Flush()
+
+	pg.writing.Lock()
+	defer pg.writing.Unlock()
+	if pg.connector == nil {
+		return err
+	}
+	if closeErr := pg.connector.Close(); err == nil {
+		err = closeErr
+	}
+	pg.connector = nil
+	return err
+}
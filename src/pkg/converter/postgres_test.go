@@ -0,0 +1,120 @@
+package converter_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPostgresEnvKey contains the adapter configuration of a test database, eg.
+// postgres:postgres:postgres:localhost:5432:postgres
+const testPostgresEnvKey = "TMD_TEST_POSTGRES"
+
+func TestNewPostgresConverter(t *testing.T) {
+	pg, err := converter.NewPostgresConverter(enums.Games.F1(), strings.Split("postgres:user:pass:db:5432:app", ":"))
+	require.NoError(t, err)
+	assert.Equal(t, "user", pg.User)
+	assert.Equal(t, "pass", pg.Password)
+	assert.Equal(t, "db", pg.Host)
+	assert.Equal(t, "5432", pg.Port)
+	assert.Equal(t, "app", pg.Database)
+	assert.Equal(t, "tmd_f1", pg.TableName)
+	assert.False(t, pg.Timescale)
+
+	pg, err = converter.NewPostgresConverter(
+		enums.Games.F1(), strings.Split("postgres:user:pass:db:5432:app:timescale", ":"),
+	)
+	require.NoError(t, err)
+	assert.True(t, pg.Timescale)
+
+	for _, configuration := range []string{"postgres:user:pass:db:5432", "postgres:user:pass:db:5432:app:hypertable"} {
+		_, err = converter.NewPostgresConverter(enums.Games.F1(), strings.Split(configuration, ":"))
+		assert.ErrorIs(t, err, converter.ErrInvalidPostgresAdapterConfiguration, configuration)
+	}
+}
+
+func TestPostgresConverter_Convert(t *testing.T) {
+	configuration := os.Getenv(testPostgresEnvKey)
+	if configuration == "" {
+		t.Skipf("%s is not set", testPostgresEnvKey)
+	}
+	t.Setenv(converter.DriversEnvKey, "192.168.1.10=alice")
+	t.Setenv("USER_ID", "")
+	pg, err := converter.NewPostgresConverter(enums.Games.ForzaMotorsport2023(), strings.Split(configuration, ":"))
+	require.NoError(t, err)
+	pg.TableName = fmt.Sprintf("tmd_test_%d", time.Now().UnixNano())
+
+	parts := strings.Split(configuration, ":")
+	database, err := sql.Open("postgres", fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable", parts[1], parts[2], parts[3], parts[4], parts[5],
+	))
+	require.NoError(t, err)
+	defer database.Close()
+	defer database.Exec("DROP TABLE IF EXISTS " + pq.QuoteIdentifier(pg.TableName))
+
+	data := telemetry.GameData{
+		Keys: []string{"IsRaceOn", "TimestampMS", "Speed"},
+		Data: map[string]float32{"IsRaceOn": 1, "TimestampMS": 38404609, "Speed": 42.5},
+		Values: map[string]telemetry.Value{
+			"IsRaceOn":    {DataType: "S32", Int: 1},
+			"TimestampMS": {DataType: "U32", Int: 38404609},
+			"Speed":       {DataType: "F32", Float: 42.5},
+		},
+		Normalized: &telemetry.Normalized{IsRaceOn: true},
+		Source:     netip.MustParseAddrPort("192.168.1.10:50001"),
+	}
+	pg.Convert(time.Now(), data, 9999)
+	require.NoError(t, pg.Flush())
+
+	// the channel sent later is added to the table
+	data.Keys = append(data.Keys, "Gear")
+	data.Values["Gear"] = telemetry.Value{DataType: "U8", Int: 3}
+	data.Source = netip.AddrPort{}
+	pg.Convert(time.Now(), data, 9999)
+	require.NoError(t, pg.Close())
+
+	var columnType string
+	require.NoError(t, database.QueryRow(
+		"SELECT data_type FROM information_schema.columns WHERE table_name = $1 AND column_name = 'TimestampMS'",
+		pg.TableName,
+	).Scan(&columnType))
+	assert.Equal(t, "bigint", columnType)
+
+	rows, err := database.Query(fmt.Sprintf(
+		`SELECT source, driver, "TimestampMS", "Speed", "Gear" FROM %s ORDER BY time`, pq.QuoteIdentifier(pg.TableName),
+	))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		source, driver sql.NullString
+		timestamp      int64
+		speed          float32
+		gear           sql.NullInt16
+	}
+	var stored []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.source, &r.driver, &r.timestamp, &r.speed, &r.gear))
+		stored = append(stored, r)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []row{
+		{
+			source: sql.NullString{String: "192.168.1.10:50001", Valid: true},
+			driver: sql.NullString{String: "alice", Valid: true}, timestamp: 38404609, speed: 42.5,
+		},
+		{timestamp: 38404609, speed: 42.5, gear: sql.NullInt16{Int16: 3, Valid: true}},
+	}, stored)
+}
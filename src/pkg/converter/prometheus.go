@@ -0,0 +1,355 @@
+package converter
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusPath is the path of the metrics endpoint
+const PrometheusPath = "/metrics"
+
+var ErrInvalidPrometheusAdapterConfiguration = errors.New("[Prometheus] invalid adapter configuration")
+
+// metricsServers contains the running metrics endpoints by the listen address,
+// the adapters of every game and port configured with the same address share the endpoint
+var metricsServers = struct {
+	mu        sync.Mutex
+	byAddress map[string]*metricsServer
+}{byAddress: map[string]*metricsServer{}}
+
+// metricsServer is the collector of the metrics of its adapters, served with its own registry
+type metricsServer struct {
+	server   *http.Server
+	mu       sync.Mutex
+	adapters []*PrometheusExporter
+}
+
+// PrometheusExporter serves the latest normalized sample of every game sending to the port as gauges,
+// with the counters of the converted packets and the completed laps and the pipeline counters, see telemetry.Metrics.
+type PrometheusExporter struct {
+	ConverterData
+	Address string
+	port    int
+	mu      sync.Mutex
+	samples map[netip.AddrPort]*latestSample
+	packets uint64
+}
+
+// latestSample is the last sample of a game, the normalized sample is copied as the published data is reused
+type latestSample struct {
+	sample    telemetry.Normalized
+	updatedAt time.Time
+	laps      uint64
+}
+
+// NewPrometheusExporter creates a new PrometheusExporter from the `prometheus:port` or `prometheus:host:port`
+// configuration
+func NewPrometheusExporter(game enums.Game, adapterConfiguration []string) (*PrometheusExporter, error) {
+	var address string
+	switch len(adapterConfiguration) {
+	case 2:
+		address = ":" + adapterConfiguration[1]
+	case 3:
+		address = net.JoinHostPort(adapterConfiguration[1], adapterConfiguration[2])
+	default:
+		return nil, ErrInvalidPrometheusAdapterConfiguration
+	}
+	if _, err := strconv.Atoi(adapterConfiguration[len(adapterConfiguration)-1]); err != nil {
+		return nil, errors.Wrapf(ErrInvalidPrometheusAdapterConfiguration, "[%s] %s", game, address)
+	}
+
+	return &PrometheusExporter{
+		ConverterData: ConverterData{GameName: game},
+		Address:       address,
+		samples:       map[netip.AddrPort]*latestSample{},
+	}, nil
+}
+
+func (prom *PrometheusExporter) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("PrometheusExporter ChannelInit")
+	prom.port = port
+	if err := prom.register(); err != nil {
+		log.Println(err)
+	}
+
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		prom.Convert(now, data, port)
+		data.Release()
+	})
+}
+
+// Convert stores the normalized sample of the game, a lap is completed when the lap number increases.
+// The samples of the games silent for longer than telemetry.SessionTimeout are removed.
+func (prom *PrometheusExporter) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	prom.mu.Lock()
+	defer prom.mu.Unlock()
+
+	prom.packets++
+	if data.Normalized == nil || !data.Normalized.IsPlayer {
+		return
+	}
+
+	now := time.Now()
+	latest, ok := prom.samples[data.Source]
+	if !ok {
+		for source, expired := range prom.samples {
+			if now.Sub(expired.updatedAt) > telemetry.SessionTimeout {
+				delete(prom.samples, source)
+			}
+		}
+		latest = &latestSample{}
+		prom.samples[data.Source] = latest
+	} else if data.Normalized.LapNumber > latest.sample.LapNumber {
+		latest.laps++
+	}
+	latest.sample = *data.Normalized
+	latest.updatedAt = now
+}
+
+// register starts the metrics endpoint of the address, unless it is served already for another adapter
+func (prom *PrometheusExporter) register() error {
+	metricsServers.mu.Lock()
+	defer metricsServers.mu.Unlock()
+
+	endpoint, ok := metricsServers.byAddress[prom.Address]
+	if !ok {
+		listener, err := net.Listen("tcp", prom.Address)
+		if err != nil {
+			return errors.Wrapf(err, "[Prometheus] could not listen on %s", prom.Address)
+		}
+		endpoint = &metricsServer{}
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(endpoint)
+		mux := http.NewServeMux()
+		mux.Handle(PrometheusPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: log.Default()}))
+		endpoint.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := endpoint.server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				log.Println(err)
+			}
+		}()
+		metricsServers.byAddress[prom.Address] = endpoint
+		log.Printf("[Prometheus] serving the metrics on %s%s", listener.Addr(), PrometheusPath)
+	}
+
+	endpoint.mu.Lock()
+	endpoint.adapters = append(endpoint.adapters, prom)
+	endpoint.mu.Unlock()
+	return nil
+}
+
+// Close removes the adapter from the metrics endpoint, the endpoint is stopped with its last adapter
+func (prom *PrometheusExporter) Close() error {
+	metricsServers.mu.Lock()
+	defer metricsServers.mu.Unlock()
+
+	endpoint, ok := metricsServers.byAddress[prom.Address]
+	if !ok {
+		return nil
+	}
+	endpoint.mu.Lock()
+	for i, adapter := range endpoint.adapters {
+		if adapter == prom {
+			endpoint.adapters = append(endpoint.adapters[:i], endpoint.adapters[i+1:]...)
+			break
+		}
+	}
+	last := len(endpoint.adapters) == 0
+	endpoint.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	delete(metricsServers.byAddress, prom.Address)
+	return endpoint.server.Close()
+}
+
+// Describe sends the descriptions of the adapter and the pipeline metrics
+func (m *metricsServer) Describe(descs chan<- *prometheus.Desc) {
+	descs <- packetsConvertedDesc
+	for _, gauge := range sampleGauges {
+		descs <- gauge.desc
+	}
+	descs <- tireTemperatureDesc
+	descs <- lastUpdateDesc
+	descs <- lapsCompletedDesc
+	descs <- packetsReceivedDesc
+	descs <- packetsRejectedDesc
+	descs <- adapterPublishedDesc
+	descs <- adapterDroppedDesc
+}
+
+// Collect sends the metrics of every adapter of the endpoint and the pipeline counters
+func (m *metricsServer) Collect(metrics chan<- prometheus.Metric) {
+	m.mu.Lock()
+	for _, adapter := range m.adapters {
+		adapter.collect(metrics)
+	}
+	m.mu.Unlock()
+	collectPipeline(metrics)
+}
+
+// sampleGauge is a gauge of the latest normalized sample
+type sampleGauge struct {
+	desc  *prometheus.Desc
+	value func(sample *telemetry.Normalized) float32
+}
+
+// sampleLabels are the labels of the metrics of the latest sample
+var sampleLabels = []string{"game", "port", "source"}
+
+var (
+	packetsConvertedDesc = prometheus.NewDesc(
+		"tmd_packets_converted_total", "Packets received by the Prometheus adapter.", []string{"game", "port"}, nil,
+	)
+	tireTemperatureDesc = prometheus.NewDesc(
+		"tmd_tire_temperature_celsius", "Tire temperature.", append(sampleLabels, "wheel"), nil,
+	)
+	lastUpdateDesc = prometheus.NewDesc(
+		"tmd_last_update_timestamp_seconds", "Time of the latest sample.", sampleLabels, nil,
+	)
+	lapsCompletedDesc = prometheus.NewDesc(
+		"tmd_laps_completed_total", "Laps completed since the first sample.", sampleLabels, nil,
+	)
+	packetsReceivedDesc = prometheus.NewDesc(
+		"tmd_packets_received_total", "Packets received by the listener.", []string{"port"}, nil,
+	)
+	packetsRejectedDesc = prometheus.NewDesc(
+		"tmd_packets_rejected_total", "Packets rejected by the game decoder.", []string{"port", "reason"}, nil,
+	)
+	adapterPublishedDesc = prometheus.NewDesc(
+		"tmd_adapter_packets_published_total", "Packets queued for the adapter.", []string{"port", "adapter"}, nil,
+	)
+	adapterDroppedDesc = prometheus.NewDesc(
+		"tmd_adapter_packets_dropped_total", "Packets dropped as the adapter queue was full.",
+		[]string{"port", "adapter"}, nil,
+	)
+)
+
+// sampleGauges are the gauges exported for every game sending to the port
+var sampleGauges = []sampleGauge{
+	newSampleGauge("tmd_race_on", "1 when the race is on.", func(s *telemetry.Normalized) float32 {
+		return boolValue(s.IsRaceOn)
+	}),
+	newSampleGauge("tmd_speed_meters_per_second", "Speed of the car.", func(s *telemetry.Normalized) float32 {
+		return s.Speed
+	}),
+	newSampleGauge("tmd_engine_rpm", "Engine RPM.", func(s *telemetry.Normalized) float32 {
+		return s.RPM
+	}),
+	newSampleGauge("tmd_engine_max_rpm", "Maximum engine RPM.", func(s *telemetry.Normalized) float32 {
+		return s.MaxRPM
+	}),
+	newSampleGauge("tmd_gear", "Gear, -1 in the reverse and 0 in the neutral.", func(s *telemetry.Normalized) float32 {
+		return float32(s.Gear)
+	}),
+	newSampleGauge("tmd_throttle_ratio", "Throttle from 0 to 1.", func(s *telemetry.Normalized) float32 {
+		return s.Throttle
+	}),
+	newSampleGauge("tmd_brake_ratio", "Brake from 0 to 1.", func(s *telemetry.Normalized) float32 {
+		return s.Brake
+	}),
+	newSampleGauge("tmd_fuel_ratio", "Fuel left as the fraction of the tank.", func(s *telemetry.Normalized) float32 {
+		return s.Fuel
+	}),
+	newSampleGauge("tmd_lap_number", "Number of the completed laps in the session.",
+		func(s *telemetry.Normalized) float32 {
+			return float32(s.LapNumber)
+		}),
+	newSampleGauge("tmd_race_position", "Position in the race.", func(s *telemetry.Normalized) float32 {
+		return float32(s.RacePosition)
+	}),
+	newSampleGauge("tmd_current_lap_seconds", "Time of the current lap.", func(s *telemetry.Normalized) float32 {
+		return s.CurrentLap
+	}),
+	newSampleGauge("tmd_last_lap_seconds", "Time of the last lap.", func(s *telemetry.Normalized) float32 {
+		return s.LastLap
+	}),
+	newSampleGauge("tmd_best_lap_seconds", "Time of the best lap.", func(s *telemetry.Normalized) float32 {
+		return s.BestLap
+	}),
+}
+
+func newSampleGauge(name, help string, value func(sample *telemetry.Normalized) float32) sampleGauge {
+	return sampleGauge{desc: prometheus.NewDesc(name, help, sampleLabels, nil), value: value}
+}
+
+// collect sends the gauges of the latest samples and the adapter counters
+func (prom *PrometheusExporter) collect(metrics chan<- prometheus.Metric) {
+	prom.mu.Lock()
+	defer prom.mu.Unlock()
+
+	game, port := string(prom.GameName), strconv.Itoa(prom.port)
+	metrics <- prometheus.MustNewConstMetric(
+		packetsConvertedDesc, prometheus.CounterValue, float64(prom.packets), game, port,
+	)
+
+	for source, latest := range prom.samples {
+		sample := &latest.sample
+		labels := []string{game, port, sourceLabel(source)}
+		for _, gauge := range sampleGauges {
+			metrics <- prometheus.MustNewConstMetric(
+				gauge.desc, prometheus.GaugeValue, float64(gauge.value(sample)), labels...,
+			)
+		}
+		for i, wheel := range telemetry.WheelSuffixes {
+			if sample.HasTireTemperature {
+				metrics <- prometheus.MustNewConstMetric(
+					tireTemperatureDesc, prometheus.GaugeValue, float64(sample.TireTemperature[i]),
+					append(labels, wheel)...,
+				)
+			}
+		}
+		metrics <- prometheus.MustNewConstMetric(
+			lastUpdateDesc, prometheus.GaugeValue, float64(latest.updatedAt.UnixMilli())/1000, labels...,
+		)
+		metrics <- prometheus.MustNewConstMetric(
+			lapsCompletedDesc, prometheus.CounterValue, float64(latest.laps), labels...,
+		)
+	}
+}
+
+// collectPipeline sends the counters of the listeners and the adapter queues
+func collectPipeline(metrics chan<- prometheus.Metric) {
+	for _, listener := range telemetry.Metrics() {
+		port := strconv.Itoa(listener.Port)
+		metrics <- prometheus.MustNewConstMetric(
+			packetsReceivedDesc, prometheus.CounterValue, float64(listener.Received), port,
+		)
+		for reason, rejected := range listener.Rejected {
+			metrics <- prometheus.MustNewConstMetric(
+				packetsRejectedDesc, prometheus.CounterValue, float64(rejected), port, reason,
+			)
+		}
+		for _, adapter := range listener.Adapters {
+			metrics <- prometheus.MustNewConstMetric(
+				adapterPublishedDesc, prometheus.CounterValue, float64(adapter.Published), port, adapter.Adapter,
+			)
+			metrics <- prometheus.MustNewConstMetric(
+				adapterDroppedDesc, prometheus.CounterValue, float64(adapter.Dropped), port, adapter.Adapter,
+			)
+		}
+	}
+}
+
+// sourceLabel returns the address of the game, or unknown for the data without the address
+func sourceLabel(source netip.AddrPort) string {
+	if !source.IsValid() {
+		return "unknown"
+	}
+	return source.String()
+}
@@ -0,0 +1,117 @@
+package converter_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrometheusExporter(t *testing.T) {
+	exporter, err := converter.NewPrometheusExporter(enums.Games.F1(), []string{"prometheus", "9100"})
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", exporter.Address)
+
+	exporter, err = converter.NewPrometheusExporter(enums.Games.F1(), []string{"prometheus", "127.0.0.1", "9100"})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9100", exporter.Address)
+
+	for _, configuration := range []string{"prometheus", "prometheus:metrics", "prometheus:a:b:c"} {
+		_, err = converter.NewPrometheusExporter(enums.Games.F1(), strings.Split(configuration, ":"))
+		assert.ErrorIs(t, err, converter.ErrInvalidPrometheusAdapterConfiguration, configuration)
+	}
+}
+
+func TestPrometheusExporter_Metrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	metricsPort := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, listener.Close())
+
+	// two games share the endpoint
+	configuration := []string{"prometheus", "127.0.0.1", metricsPort}
+	forza, err := converter.NewPrometheusExporter(enums.Games.ForzaMotorsport2023(), configuration)
+	require.NoError(t, err)
+	f1, err := converter.NewPrometheusExporter(enums.Games.F1(), configuration)
+	require.NoError(t, err)
+
+	bus := telemetry.NewBus([]telemetry.ConverterInterface{forza}, telemetry.DefaultQueueSize)
+	bus.Start(time.Now(), 39999)
+	f1Bus := telemetry.NewBus([]telemetry.ConverterInterface{f1}, telemetry.DefaultQueueSize)
+	f1Bus.Start(time.Now(), 39998)
+
+	handler := &telemetry.TelemetryHandler{}
+	handler.Process(server.Packet{Data: []byte{1}}, 39999, func(server.Packet, int) error {
+		return errors.WithMessage(telemetry.ErrUnknownFormat, "[Test]")
+	})
+
+	source := netip.MustParseAddrPort("192.168.1.10:50001")
+	for lap := 2; lap <= 3; lap++ {
+		bus.Publish(telemetry.GameData{
+			Normalized: &telemetry.Normalized{
+				IsRaceOn: true, IsPlayer: true, Speed: 42.5, RPM: 7200, Gear: 4, Fuel: 0.5, LapNumber: lap,
+				TireTemperature: telemetry.Wheels{80, 81, 90, 91}, HasTireTemperature: true,
+			},
+			Source: source,
+		})
+	}
+	// the data of the other cars is not exported
+	bus.Publish(telemetry.GameData{Normalized: &telemetry.Normalized{Speed: 10}, Source: source})
+	// the tire temperatures are not exported for the games without them
+	f1Bus.Publish(telemetry.GameData{Normalized: &telemetry.Normalized{IsPlayer: true, Speed: 20}, Source: source})
+
+	var metrics string
+	require.Eventually(t, func() bool {
+		metrics = scrape(t, "http://127.0.0.1:"+metricsPort+converter.PrometheusPath)
+		return strings.Contains(metrics, `tmd_packets_converted_total{game="fms2023",port="39999"} 3`) &&
+			strings.Contains(metrics, `tmd_packets_converted_total{game="f1",port="39998"} 1`)
+	}, time.Second, 10*time.Millisecond)
+
+	labels := `{game="fms2023",port="39999",source="192.168.1.10:50001"}`
+	for _, expected := range []string{
+		"# TYPE tmd_speed_meters_per_second gauge",
+		"tmd_speed_meters_per_second" + labels + " 42.5",
+		"tmd_engine_rpm" + labels + " 7200",
+		"tmd_gear" + labels + " 4",
+		"tmd_fuel_ratio" + labels + " 0.5",
+		`tmd_tire_temperature_celsius{game="fms2023",port="39999",source="192.168.1.10:50001",wheel="RearLeft"} 90`,
+		"# TYPE tmd_laps_completed_total counter",
+		"tmd_laps_completed_total" + labels + " 1",
+		`tmd_speed_meters_per_second{game="f1",port="39998",source="192.168.1.10:50001"} 20`,
+		`tmd_packets_received_total{port="39999"} 1`,
+		`tmd_packets_rejected_total{port="39999",reason="unknown_format"} 1`,
+		`tmd_adapter_packets_published_total{adapter="*converter.PrometheusExporter",port="39999"} 3`,
+		`tmd_adapter_packets_dropped_total{adapter="*converter.PrometheusExporter",port="39998"} 0`,
+	} {
+		assert.Contains(t, metrics, expected+"\n")
+	}
+	assert.Equal(t, 1, strings.Count(metrics, "# TYPE tmd_packets_converted_total counter"))
+	assert.NotContains(t, metrics, `tmd_tire_temperature_celsius{game="f1"`)
+
+	require.NoError(t, bus.Close())
+	assert.NotContains(t, scrape(t, "http://127.0.0.1:"+metricsPort+converter.PrometheusPath), `game="fms2023"`)
+	require.NoError(t, f1Bus.Close())
+	_, err = http.Get("http://127.0.0.1:" + metricsPort + converter.PrometheusPath)
+	assert.Error(t, err, "the endpoint is stopped with the last adapter")
+}
+
+func scrape(t *testing.T, url string) string {
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(body)
+}
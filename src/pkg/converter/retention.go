@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/spf13/afero"
+)
+
+var (
+	ErrInvalidFilePath  = errors.New("[Retention] invalid file path")
+	ErrInvalidRetention = errors.New("[Retention] invalid retention type")
+)
+
+// retentionFilePath returns the file path of the file adapters based on the retention type, the extension
+// is the file type written by the adapter, eg. ".csv". The daily retention needs a path to a directory,
+// the file of the day is created in it. The none retention needs a path to a directory or a file.
+func retentionFilePath(
+	afs *afero.Afero, game enums.Game, filePath string, retention enums.RetentionType, extension string, now time.Time,
+) (string, error) {
+	switch retention {
+	case enums.RetentionTypes.Daily():
+		return dailyRetention(afs, game, filePath, extension, now)
+	case enums.RetentionTypes.None():
+		return noRetention(afs, game, filePath, extension)
+	}
+
+	return "", ErrInvalidRetention
+}
+
+// dailyRetention validate and returns the file path for daily retention
+func dailyRetention(afs *afero.Afero, game enums.Game, filePath, extension string, now time.Time) (string, error) {
+	isDir, err := afs.IsDir(filePath)
+	if err != nil || !isDir {
+		return "", ErrInvalidFilePath
+	}
+
+	defaultFileName := fmt.Sprintf("%s-daily-%s%s", game, now.Format("2006-01-02"), extension)
+	return withTrailingSlash(filePath) + defaultFileName, nil
+}
+
+// noRetention validate and returns the file path for no retention type
+func noRetention(afs *afero.Afero, game enums.Game, filePath, extension string) (string, error) {
+	defaultFileName := fmt.Sprintf("%s%s", game, extension)
+
+	dir, file := filepath.Split(filePath)
+
+	if file == "" {
+		isDir, err := afs.IsDir(dir)
+		if err != nil || !isDir {
+			return "", ErrInvalidFilePath
+		}
+
+		return filePath + defaultFileName, nil
+	}
+
+	fileExt := filepath.Ext(filePath)
+	isDir, err := afs.IsDir(filePath)
+	if fileExt != extension && (err != nil || !isDir) {
+		return "", ErrInvalidFilePath
+	}
+
+	if fileExt == extension {
+		return filePath, nil
+	}
+
+	return withTrailingSlash(filePath) + defaultFileName, nil
+}
+
+func withTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path
+	}
+	return path + "/"
+}
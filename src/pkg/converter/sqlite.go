@@ -0,0 +1,458 @@
+package converter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// SQLiteBatchSize is the number of telemetry rows inserted in a single transaction
+	SQLiteBatchSize = 500
+	// SQLiteFlushInterval is the longest time a telemetry row waits in the batch
+	SQLiteFlushInterval = time.Second
+	// sqliteTimeFormat is the CURRENT_TIMESTAMP format with milliseconds, in UTC as CURRENT_TIMESTAMP
+	sqliteTimeFormat = "2006-01-02 15:04:05.000"
+)
+
+var (
+	ErrInvalidSQLiteAdapterConfiguration = errors.New("[SQLite] invalid adapter configuration")
+	ErrSQLiteWrite                       = errors.New("[SQLite] write failed")
+)
+
+// SQLiteConverter writes the data to a local SQLite database, no database server is needed.
+// The telemetry and the best laps tables are named and laid out as the MySQL tables, so the same queries work,
+// the sessions and the laps tables are only written by this adapter. Several games can share the file.
+type SQLiteConverter struct {
+	ConverterData
+	FilePath, TableName string
+	BatchSize           int
+	FlushInterval       time.Duration
+	userId              string
+	drivers             Drivers
+
+	// mu guards the connection, the schema state, the batch and the sessions
+	mu        sync.Mutex
+	connector *sql.DB
+	// columns are the channel columns of the telemetry table, columnIndex is the position in columns by the key
+	columns     []string
+	columnIndex map[string]int
+	rows        []sqliteRow
+	sessions    map[netip.AddrPort]*sqliteSession
+}
+
+type sqliteRow struct {
+	createdAt string
+	sessionID int64
+	values    []any
+}
+
+// sqliteSession is a drive of a source with the same car on the same track
+type sqliteSession struct {
+	id                 int64
+	carID, trackID     int
+	lapNumber          int
+	lastSeen, recorded time.Time
+}
+
+// NewSQLiteConverter creates a new SQLiteConverter from the `sqlite:path` configuration,
+// the path can contain colons, eg. the Windows drive
+func NewSQLiteConverter(game enums.Game, adapterConfiguration []string) (*SQLiteConverter, error) {
+	if len(adapterConfiguration) < 2 || adapterConfiguration[1] == "" {
+		return nil, ErrInvalidSQLiteAdapterConfiguration
+	}
+	drivers, err := ParseDrivers(os.Getenv(DriversEnvKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteConverter{
+		ConverterData: ConverterData{GameName: game},
+		FilePath:      strings.Join(adapterConfiguration[1:], ":"),
+		TableName:     gameEnvKeys[game].DatabaseTable,
+		BatchSize:     SQLiteBatchSize,
+		FlushInterval: SQLiteFlushInterval,
+		userId:        os.Getenv("USER_ID"),
+		drivers:       drivers,
+		columnIndex:   map[string]int{},
+		sessions:      map[netip.AddrPort]*sqliteSession{},
+	}, nil
+}
+
+// ChannelInit converts the data until ctx is cancelled and writes the batch every FlushInterval
+func (lite *SQLiteConverter) ChannelInit(
+	ctx context.Context, now time.Time, channel chan telemetry.GameData, port int,
+) {
+	log.Println("SQLiteConverter ChannelInit")
+	flushed := flushPeriodically(ctx, lite.FlushInterval, lite.Flush)
+
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		lite.Convert(now, data, port)
+		data.Release()
+	})
+	<-flushed
+}
+
+// Convert records the sessions and the laps of the player car and adds the race data to the telemetry batch.
+// A session starts when the car or the track changes, the lap number goes back or the source was silent
+// for longer than telemetry.SessionTimeout. A lap is recorded when the lap number increases.
+func (lite *SQLiteConverter) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	sample := data.Normalized
+	if sample == nil || !sample.IsPlayer {
+		return
+	}
+
+	lite.mu.Lock()
+	defer lite.mu.Unlock()
+
+	if err := lite.open(); err != nil {
+		log.Println(err)
+		return
+	}
+
+	now := time.Now()
+	userID := lite.drivers.UserID(data.Source, lite.userId)
+	session := lite.sessions[data.Source]
+	if session != nil && (session.carID != sample.CarID || session.trackID != sample.TrackID ||
+		sample.LapNumber < session.lapNumber || now.Sub(session.lastSeen) > telemetry.SessionTimeout) {
+		session = nil
+	}
+	if session == nil {
+		if !sample.IsRaceOn {
+			return
+		}
+		var err error
+		if session, err = lite.startSession(data.Source, userID, sample, now); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+	session.lastSeen = now
+
+	if sample.LapNumber > session.lapNumber && sample.LastLap > 0 {
+		if err := lite.insertLap(session, userID, data); err != nil {
+			log.Println(err)
+		}
+	}
+	session.lapNumber = sample.LapNumber
+
+	if !sample.IsRaceOn {
+		return
+	}
+	if err := lite.addRow(session, data, now); err != nil {
+		log.Println(err)
+		return
+	}
+	if len(lite.rows) >= lite.BatchSize {
+		if err := lite.flush(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// open opens the database in the WAL mode and creates the tables, the busy timeout lets the adapters
+// of several games write to the same file
+func (lite *SQLiteConverter) open() error {
+	if lite.connector != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(lite.FilePath), 0o755); err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "%s: %v", lite.FilePath, err)
+	}
+	connector, err := sql.Open("sqlite", "file:"+lite.FilePath+
+		"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "%s: %v", lite.FilePath, err)
+	}
+	// a single connection, SQLite has one writer
+	connector.SetMaxOpenConns(1)
+
+	table := lite.TableName
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS "` + table + `_sessions" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source TEXT,
+			user_id INTEGER,
+			CarOrdinal INTEGER,
+			TrackOrdinal INTEGER,
+			started_at TEXT NOT NULL,
+			ended_at TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS "` + table + `" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			session_id INTEGER REFERENCES "` + table + `_sessions" (id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS "` + table + `_session_id" ON "` + table + `" (session_id)`,
+		`CREATE TABLE IF NOT EXISTS "` + table + `_laps" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			session_id INTEGER NOT NULL REFERENCES "` + table + `_sessions" (id),
+			user_id INTEGER,
+			CarOrdinal INTEGER,
+			TrackOrdinal INTEGER,
+			LapNumber INTEGER,
+			LapTime REAL,
+			Fuel REAL,
+			RacePosition INTEGER
+		)`,
+		`CREATE TABLE IF NOT EXISTS "` + table + `_bestlaps" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			user_id INTEGER NOT NULL,
+			CarOrdinal INTEGER,
+			TrackOrdinal INTEGER,
+			BestLap REAL,
+			CarClass INTEGER,
+			CarPerformanceIndex INTEGER,
+			DrivetrainType INTEGER,
+			NumCylinders INTEGER,
+			Fuel REAL,
+			LapNumber INTEGER,
+			RacePosition INTEGER,
+			UNIQUE (CarOrdinal, CarPerformanceIndex, BestLap, TrackOrdinal, user_id)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err = connector.Exec(statement); err != nil {
+			_ = connector.Close()
+			return errors.Wrapf(ErrSQLiteWrite, "%s: %v", lite.FilePath, err)
+		}
+	}
+
+	// the channel columns of the table created before
+	rows, err := connector.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		_ = connector.Close()
+		return errors.Wrapf(ErrSQLiteWrite, "%s: %v", lite.FilePath, err)
+	}
+	defer rows.Close()
+	lite.columns, lite.columnIndex = nil, map[string]int{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			_ = connector.Close()
+			return errors.Wrapf(ErrSQLiteWrite, "%s: %v", lite.FilePath, err)
+		}
+		if name != "id" && name != "created_at" && name != "session_id" {
+			lite.columnIndex[name] = len(lite.columns)
+			lite.columns = append(lite.columns, name)
+		}
+	}
+
+	lite.connector = connector
+	return nil
+}
+
+func (lite *SQLiteConverter) startSession(
+	source netip.AddrPort, userID string, sample *telemetry.Normalized, now time.Time,
+) (*sqliteSession, error) {
+	var sourceValue, userValue any
+	if source.IsValid() {
+		sourceValue = source.String()
+	}
+	if userID != "" {
+		userValue = userID
+	}
+	result, err := lite.connector.Exec(
+		`INSERT INTO "`+lite.TableName+`_sessions" (source, user_id, CarOrdinal, TrackOrdinal, started_at, ended_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		sourceValue, userValue, sample.CarID, sample.TrackID, sqliteTime(now), sqliteTime(now),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(ErrSQLiteWrite, "session: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrapf(ErrSQLiteWrite, "session: %v", err)
+	}
+
+	session := &sqliteSession{id: id, carID: sample.CarID, trackID: sample.TrackID, lapNumber: sample.LapNumber}
+	lite.sessions[source] = session
+	return session, nil
+}
+
+// insertLap records the lap of the session and the best lap as the mysql_bl adapter,
+// the best lap already stored is ignored
+func (lite *SQLiteConverter) insertLap(session *sqliteSession, userID string, data telemetry.GameData) error {
+	sample := data.Normalized
+	var userValue any
+	if userID != "" {
+		userValue = userID
+	}
+	_, err := lite.connector.Exec(
+		`INSERT INTO "`+lite.TableName+`_laps"
+		(session_id, user_id, CarOrdinal, TrackOrdinal, LapNumber, LapTime, Fuel, RacePosition)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.id, userValue, sample.CarID, sample.TrackID, sample.LapNumber, sample.LastLap, sample.Fuel,
+		sample.RacePosition,
+	)
+	if err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "lap: %v", err)
+	}
+
+	_, err = lite.connector.Exec(
+		`INSERT OR IGNORE INTO "`+lite.TableName+`_bestlaps"
+		(CarOrdinal, CarClass, CarPerformanceIndex, DrivetrainType, NumCylinders,
+		Fuel, BestLap, LapNumber, RacePosition, TrackOrdinal, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sample.CarID, sample.CarClass,
+		// only sent by Forza
+		data.Data["CarPerformanceIndex"], data.Data["DrivetrainType"], data.Data["NumCylinders"],
+		sample.Fuel, sample.LastLap, sample.LapNumber, sample.RacePosition, sample.TrackID, userID,
+	)
+	if err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "best lap: %v", err)
+	}
+	return nil
+}
+
+// addRow adds the channels of the data to the batch, the columns of the new channels are added to the table
+func (lite *SQLiteConverter) addRow(session *sqliteSession, data telemetry.GameData, now time.Time) error {
+	row := sqliteRow{createdAt: sqliteTime(now), sessionID: session.id, values: make([]any, 0, len(data.Keys))}
+	for _, key := range data.Keys {
+		index, ok := lite.columnIndex[key]
+		if !ok {
+			_, err := lite.connector.Exec(fmt.Sprintf(
+				`ALTER TABLE "%s" ADD COLUMN %s %s`, lite.TableName, sqliteIdentifier(key), sqliteType(data, key),
+			))
+			if err != nil {
+				return errors.Wrapf(ErrSQLiteWrite, "column %s: %v", key, err)
+			}
+			index = len(lite.columns)
+			lite.columnIndex[key] = index
+			lite.columns = append(lite.columns, key)
+		}
+		for len(row.values) <= index {
+			row.values = append(row.values, nil)
+		}
+		row.values[index] = data.Native(key)
+	}
+	lite.rows = append(lite.rows, row)
+	return nil
+}
+
+// sqliteType returns the column type of the key, the channels without the native value are stored as real
+func sqliteType(data telemetry.GameData, key string) string {
+	if value, ok := data.Values[key]; ok && !value.IsFloat() {
+		return "INTEGER"
+	}
+	return "REAL"
+}
+
+// sqliteIdentifier quotes the column name
+func sqliteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// Flush inserts the telemetry batch and updates the end of the active sessions in a single transaction,
+// the batch is dropped when the transaction fails
+func (lite *SQLiteConverter) Flush() error {
+	lite.mu.Lock()
+	defer lite.mu.Unlock()
+
+	return lite.flush()
+}
+
+func (lite *SQLiteConverter) flush() error {
+	rows := lite.rows
+	lite.rows = nil
+	if lite.connector == nil {
+		return nil
+	}
+
+	tx, err := lite.connector.Begin()
+	if err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "%d rows: %v", len(rows), err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if len(rows) > 0 {
+		columns := make([]string, 0, len(lite.columns)+2)
+		columns = append(columns, "created_at", "session_id")
+		for _, column := range lite.columns {
+			columns = append(columns, sqliteIdentifier(column))
+		}
+		statement, err := tx.Prepare(fmt.Sprintf(
+			`INSERT INTO "%s" (%s) VALUES (?%s)`,
+			lite.TableName, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)-1),
+		))
+		if err != nil {
+			return errors.Wrapf(ErrSQLiteWrite, "%d rows: %v", len(rows), err)
+		}
+		defer statement.Close()
+
+		values := make([]any, len(columns))
+		for _, row := range rows {
+			clear(values)
+			values[0], values[1] = row.createdAt, row.sessionID
+			copy(values[2:], row.values)
+			if _, err = statement.Exec(values...); err != nil {
+				return errors.Wrapf(ErrSQLiteWrite, "%d rows: %v", len(rows), err)
+			}
+		}
+	}
+
+	var updated []*sqliteSession
+	for _, session := range lite.sessions {
+		if !session.lastSeen.After(session.recorded) {
+			continue
+		}
+		_, err = tx.Exec(
+			`UPDATE "`+lite.TableName+`_sessions" SET ended_at = ? WHERE id = ?`,
+			sqliteTime(session.lastSeen), session.id,
+		)
+		if err != nil {
+			return errors.Wrapf(ErrSQLiteWrite, "session: %v", err)
+		}
+		updated = append(updated, session)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(ErrSQLiteWrite, "%d rows: %v", len(rows), err)
+	}
+	for _, session := range updated {
+		session.recorded = session.lastSeen
+	}
+	now := time.Now()
+	for source, session := range lite.sessions {
+		if now.Sub(session.lastSeen) > telemetry.SessionTimeout {
+			delete(lite.sessions, source)
+		}
+	}
+	telemetry.DisplayLog("vvv", fmt.Sprintf("[SQLite] %d rows written", len(rows)))
+	return nil
+}
+
+// Close writes the rest of the batch and closes the database
+func (lite *SQLiteConverter) Close() error {
+	lite.mu.Lock()
+	defer lite.mu.Unlock()
+
+	err := lite.flush()
+	if lite.connector == nil {
+		return err
+	}
+	if closeErr := lite.connector.Close(); err == nil {
+		err = closeErr
+	}
+	lite.connector = nil
+	clear(lite.sessions)
+	return err
+}
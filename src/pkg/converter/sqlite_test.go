@@ -0,0 +1,108 @@
+package converter_test
+
+import (
+	"database/sql"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSQLiteConverter(t *testing.T) {
+	lite, err := converter.NewSQLiteConverter(enums.Games.F1(), strings.Split(`sqlite:C:\telemetry\tmd.db`, ":"))
+	require.NoError(t, err)
+	assert.Equal(t, `C:\telemetry\tmd.db`, lite.FilePath)
+	assert.Equal(t, "tmd_f1", lite.TableName)
+
+	for _, configuration := range []string{"sqlite", "sqlite:"} {
+		_, err = converter.NewSQLiteConverter(enums.Games.F1(), strings.Split(configuration, ":"))
+		assert.ErrorIs(t, err, converter.ErrInvalidSQLiteAdapterConfiguration, configuration)
+	}
+}
+
+func TestSQLiteConverter_Convert(t *testing.T) {
+	t.Setenv(converter.DriversEnvKey, "192.168.1.10=1")
+	t.Setenv("USER_ID", "2")
+	path := filepath.Join(t.TempDir(), "data", "tmd.db")
+	lite, err := converter.NewSQLiteConverter(enums.Games.ForzaMotorsport2023(), []string{"sqlite", path})
+	require.NoError(t, err)
+
+	sample := func(raceOn bool, lap int, lastLap float32, keys ...string) telemetry.GameData {
+		return telemetry.GameData{
+			Keys: keys,
+			Data: map[string]float32{"TimestampMS": 38404609, "Speed": 42.5, "Gear": 3, "CarPerformanceIndex": 800},
+			Values: map[string]telemetry.Value{
+				"TimestampMS": {DataType: "U32", Int: 38404609},
+				"Speed":       {DataType: "F32", Float: 42.5},
+				"Gear":        {DataType: "U8", Int: 3},
+			},
+			Normalized: &telemetry.Normalized{
+				IsRaceOn: raceOn, IsPlayer: true, CarID: 2345, TrackID: 11, LapNumber: lap, LastLap: lastLap,
+			},
+			Source: netip.MustParseAddrPort("192.168.1.10:50001"),
+		}
+	}
+
+	// the menu before the race does not start a session
+	lite.Convert(time.Now(), sample(false, 0, 0, "TimestampMS"), 9999)
+	lite.Convert(time.Now(), sample(true, 0, 0, "TimestampMS", "Speed"), 9999)
+	require.NoError(t, lite.Flush())
+	// the channel sent later is added to the table
+	lite.Convert(time.Now(), sample(true, 1, 95.5, "TimestampMS", "Speed", "Gear"), 9999)
+	lite.Convert(time.Now(), sample(true, 1, 95.5, "TimestampMS", "Speed", "Gear"), 9999)
+	// another player on the same port
+	other := sample(true, 0, 0, "Speed")
+	other.Source = netip.MustParseAddrPort("192.168.1.11:50001")
+	lite.Convert(time.Now(), other, 9999)
+	require.NoError(t, lite.Close())
+
+	database, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer database.Close()
+
+	var journalMode string
+	require.NoError(t, database.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+
+	var sessions, telemetryRows, gears int
+	require.NoError(t, database.QueryRow("SELECT COUNT(*) FROM tmd_forzamotorsport2023_sessions").Scan(&sessions))
+	assert.Equal(t, 2, sessions)
+	require.NoError(t, database.QueryRow(
+		"SELECT COUNT(*), COUNT(Gear) FROM tmd_forzamotorsport2023 WHERE session_id = 1 AND TimestampMS = 38404609",
+	).Scan(&telemetryRows, &gears))
+	assert.Equal(t, 3, telemetryRows)
+	assert.Equal(t, 2, gears)
+
+	var lapNumber int
+	var lapTime float64
+	require.NoError(t, database.QueryRow(
+		"SELECT LapNumber, LapTime FROM tmd_forzamotorsport2023_laps WHERE session_id = 1 AND user_id = 1",
+	).Scan(&lapNumber, &lapTime))
+	assert.Equal(t, 1, lapNumber)
+	assert.Equal(t, 95.5, lapTime)
+
+	// the MySQL best laps query
+	var bestLap float64
+	var performanceIndex int
+	require.NoError(t, database.QueryRow(
+		"SELECT MIN(BestLap), CarPerformanceIndex FROM tmd_forzamotorsport2023_bestlaps "+
+			"WHERE user_id = 1 AND TrackOrdinal = 11 GROUP BY CarOrdinal",
+	).Scan(&bestLap, &performanceIndex))
+	assert.Equal(t, 95.5, bestLap)
+	assert.Equal(t, 800, performanceIndex)
+
+	// the existing tables are reused
+	lite, err = converter.NewSQLiteConverter(enums.Games.ForzaMotorsport2023(), []string{"sqlite", path})
+	require.NoError(t, err)
+	lite.Convert(time.Now(), sample(true, 0, 0, "TimestampMS", "Gear"), 9999)
+	require.NoError(t, lite.Close())
+	require.NoError(t, database.QueryRow("SELECT COUNT(Gear) FROM tmd_forzamotorsport2023").Scan(&gears))
+	assert.Equal(t, 3, gears)
+}
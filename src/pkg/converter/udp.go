@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
@@ -28,6 +29,19 @@ type UdpClient struct {
 }
 
 func NewUdpForwarder(game enums.Game, adapterConfiguration []string) (*UdpForwarder, error) {
+	udpClientsList, err := parseUdpClients(game, adapterConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UdpForwarder{
+		ConverterData: ConverterData{GameName: game},
+		Clients:       udpClientsList,
+	}, nil
+}
+
+// parseUdpClients reads the `host:port` clients separated by `&` from the adapter configuration
+func parseUdpClients(game enums.Game, adapterConfiguration []string) ([]*UdpClient, error) {
 	udpClients := strings.Split(strings.Join(adapterConfiguration[1:], ":"), "&")
 	var udpClientsList []*UdpClient
 	for _, udpClient := range udpClients {
@@ -50,32 +64,30 @@ func NewUdpForwarder(game enums.Game, adapterConfiguration []string) (*UdpForwar
 			port: port,
 		})
 	}
-
-	return &UdpForwarder{
-		ConverterData: ConverterData{GameName: game},
-		Clients:       udpClientsList,
-	}, nil
+	return udpClientsList, nil
 }
 
-func (udp *UdpForwarder) ChannelInit(now time.Time, channel chan telemetry.GameData, port int) {
+func (udp *UdpForwarder) ChannelInit(ctx context.Context, now time.Time, channel chan telemetry.GameData, port int) {
 	log.Println("UdpForwarder ChannelInit")
-	//nolint:gosimple // loop is needed to keep the channel open
-	for {
-		select {
-		case data := <-channel:
-			udp.Convert(now, data, port)
-		}
-	}
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		udp.Convert(now, data, port)
+		data.Release()
+	})
 }
 
 // Convert converts the data to the UDP clients
 func (udp *UdpForwarder) Convert(_ time.Time, data telemetry.GameData, _ int) {
 	log.Println("UdpForwarder Convert")
+	udp.send(data.RawData)
+}
+
+// send writes the packet to every client, the connection is reopened with the next packet after a failure
+func (udp *UdpForwarder) send(packet []byte) {
 	for _, client := range udp.Clients {
 		if client.connection == nil {
 			udp.connectToClient(client)
 		}
-		_, err := client.connection.Write(data.RawData)
+		_, err := client.connection.Write(packet)
 		if err != nil {
 			if client.connection != nil {
 				client.connection.Close()
@@ -85,6 +97,17 @@ func (udp *UdpForwarder) Convert(_ time.Time, data telemetry.GameData, _ int) {
 	}
 }
 
+// Close closes the connections to the clients
+func (udp *UdpForwarder) Close() error {
+	for _, client := range udp.Clients {
+		if client.connection != nil {
+			client.connection.Close()
+			client.connection = nil
+		}
+	}
+	return nil
+}
+
 func (udp *UdpForwarder) connectToClient(client *UdpClient) {
 	log.Println("UdpForwarder connectToClient")
 	var err error
@@ -1,6 +1,18 @@
 package enums
 
-const fms2023 = "fms2023"
+const (
+	fms2023 = "fms2023"
+	fh      = "fh"
+	f1      = "f1"
+	gt7     = "gt7"
+	ac      = "ac"
+	acc     = "acc"
+	pcars2  = "pcars2"
+	dr2     = "dr2"
+	wrc     = "wrc"
+	lfs     = "lfs"
+	beamng  = "beamng"
+)
 
 type Game string
 
@@ -10,6 +22,16 @@ func (e Game) String() string {
 
 type games struct{}
 
-func (games) ForzaMotorsport2023() Game { return fms2023 }
+func (games) ForzaMotorsport2023() Game      { return fms2023 }
+func (games) ForzaHorizon() Game             { return fh }
+func (games) F1() Game                       { return f1 }
+func (games) GranTurismo7() Game             { return gt7 }
+func (games) AssettoCorsa() Game             { return ac }
+func (games) AssettoCorsaCompetizione() Game { return acc }
+func (games) ProjectCars2() Game             { return pcars2 }
+func (games) DirtRally2() Game               { return dr2 }
+func (games) EAWRC() Game                    { return wrc }
+func (games) LiveForSpeed() Game             { return lfs }
+func (games) BeamNG() Game                   { return beamng }
 
 var Games games
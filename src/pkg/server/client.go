@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+)
+
+var ErrNotConnected = errors.New("UDP client is not connected")
+
+// Client is a data source which connects to the game and requests the data,
+// instead of waiting for the game to send it to a fixed port
+type Client interface {
+	Server
+	Send(payload []byte) error
+}
+
+// NewClient creates a new Client connecting to the game address
+func NewClient(addr string) Client {
+	return &UDPClient{
+		Addr: addr,
+	}
+}
+
+type UDPClient struct {
+	Addr       string
+	connection *net.UDPConn
+	buffer     chan Packet
+}
+
+// Run connects to the game and passes every received packet to the handler.
+// The handler is responsible for the handshake, it can send it with Send. It returns when ctx is cancelled,
+// eg. after it has sent the goodbye message to the game, and the connection is closed after it.
+func (u *UDPClient) Run(ctx context.Context, fn HandleConnection, port int) (err error) {
+	raddr, err := net.ResolveUDPAddr("udp", u.Addr)
+	if err != nil {
+		return errors.New("could not resolve UDP addr")
+	}
+
+	u.connection, err = net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return errors.New("could not connect to UDP")
+	}
+	defer u.Close()
+
+	u.buffer = make(chan Packet)
+	// the connected socket receives only the packets of the game
+	source := unmap(raddr.AddrPort())
+	handled := make(chan struct{})
+
+	go func() {
+		defer close(handled)
+		defer u.Close()
+		fn(ctx, u.buffer, port)
+	}()
+
+	for {
+		// the buffers are taken from the server pool, so the handlers release them the same way, see ReleaseBuffer
+		buf := buffers.Get().(*[BufferSize]byte)
+		n, err := u.connection.Read(buf[:])
+		if errors.Is(err, net.ErrClosed) {
+			buffers.Put(buf)
+			break
+		}
+		if err != nil {
+			buffers.Put(buf)
+			// the game is not running yet, the handler repeats the handshake
+			log.Println(err)
+			continue
+		}
+
+		select {
+		case u.buffer <- Packet{Data: buf[:n], Source: source}:
+		case <-handled:
+			buffers.Put(buf)
+		}
+	}
+
+	<-handled
+	return nil
+}
+
+// Send sends the payload to the game
+func (u *UDPClient) Send(payload []byte) error {
+	if u.connection == nil {
+		return ErrNotConnected
+	}
+	_, err := u.connection.Write(payload)
+	return err
+}
+
+// Close closes the connection to the game
+func (u *UDPClient) Close() error {
+	if u.connection == nil {
+		return ErrNotConnected
+	}
+	err := u.connection.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUdpClient(t *testing.T) {
+	t.Run("should return error when could not resolve UDP addr", func(t *testing.T) {
+		udpClient := server.NewClient("invalid")
+
+		err := udpClient.Run(context.Background(), func(context.Context, chan server.Packet, int) {}, 1234)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error when sending before connecting", func(t *testing.T) {
+		udpClient := server.NewClient("127.0.0.1:9996")
+
+		assert.ErrorIs(t, udpClient.Send([]byte("handshake")), server.ErrNotConnected)
+	})
+
+	t.Run("should send requests and receive responses", func(t *testing.T) {
+		game, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		defer game.Close()
+
+		// the fake game answers every request
+		go func() {
+			buffer := make([]byte, 64)
+			for {
+				n, addr, err := game.ReadFromUDP(buffer)
+				if err != nil {
+					return
+				}
+				_, _ = game.WriteToUDP(append([]byte("response to "), buffer[:n]...), addr)
+			}
+		}()
+
+		udpClient := server.NewClient(game.LocalAddr().String())
+		responses := make(chan server.Packet)
+		go func() {
+			_ = udpClient.Run(context.Background(), func(_ context.Context, channel chan server.Packet, _ int) {
+				assert.NoError(t, udpClient.Send([]byte("handshake")))
+				responses <- <-channel
+			}, 9996)
+		}()
+
+		select {
+		case response := <-responses:
+			assert.Equal(t, "response to handshake", string(response.Data))
+			assert.Equal(t, game.LocalAddr().(*net.UDPAddr).AddrPort(), response.Source)
+			assert.Equal(t, server.BufferSize, cap(response.Data), "the buffer is taken from the server pool")
+			server.ReleaseBuffer(response.Data)
+		case <-time.After(time.Second):
+			t.Fatal("no response received")
+		}
+		assert.NoError(t, udpClient.Close())
+	})
+}
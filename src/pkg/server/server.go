@@ -1,9 +1,25 @@
 package server
 
-type HandleConnection func(channel chan []byte, port int)
+import (
+	"context"
+	"net/netip"
+)
+
+// Packet is a received datagram with the address of the game which sent it.
+// Several games, eg. two consoles, can send to the same port, the handlers keep their state by the Source.
+type Packet struct {
+	Data   []byte
+	Source netip.AddrPort
+}
+
+// HandleConnection processes the packets received from the channel.
+// The servers close the channel when they stop, the clients stop when the handler returns.
+type HandleConnection func(ctx context.Context, channel chan Packet, port int)
 
 type Server interface {
-	Run(fn HandleConnection, port int) error
+	// Run passes the received packets to the handler until ctx is cancelled,
+	// it returns once the handler has processed the last packet
+	Run(ctx context.Context, fn HandleConnection, port int) error
 	Close() error
 }
 
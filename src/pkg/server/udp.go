@@ -1,21 +1,44 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/netip"
+	"sync"
 )
 
+// BufferSize is the size of the receive buffers, longer datagrams are truncated
+const BufferSize = 2048
+
+// buffers contains the receive buffers released by the handlers, see ReleaseBuffer
+var buffers = sync.Pool{
+	New: func() any {
+		return new([BufferSize]byte)
+	},
+}
+
+// ReleaseBuffer returns the packet buffer received from the server to the pool, once the packet is not used anymore.
+// Releasing the buffers is optional, buffers which are not released are garbage collected.
+func ReleaseBuffer(buffer []byte) {
+	if cap(buffer) != BufferSize {
+		// not received from the server, eg. a packet in the tests
+		return
+	}
+	buffers.Put((*[BufferSize]byte)(buffer[:BufferSize]))
+}
+
 type UDPServer struct {
 	Addr   string
 	server *net.UDPConn
+	buffer chan Packet
 }
 
-var udpBuffer = make(chan []byte)
-
-// Run starts the UDP server.
-func (u *UDPServer) Run(fn HandleConnection, port int) (err error) {
+// Run starts the UDP server. When ctx is cancelled the server stops reading and closes the channel,
+// so the handler processes the packets already received and returns.
+func (u *UDPServer) Run(ctx context.Context, fn HandleConnection, port int) (err error) {
 	laddr, err := net.ResolveUDPAddr("udp", u.Addr)
 	if err != nil {
 		return errors.New("could not resolve UDP addr")
@@ -25,28 +48,61 @@ func (u *UDPServer) Run(fn HandleConnection, port int) (err error) {
 	if err != nil {
 		return errors.New("could not listen on UDP")
 	}
+	defer u.Close()
+	stop := context.AfterFunc(ctx, func() { u.Close() })
+	defer stop()
+
+	u.buffer = make(chan Packet)
+	handled := make(chan struct{})
 
 	fmt.Println("UPD fn goroutine")
-	go fn(udpBuffer, port)
+	go func() {
+		defer close(handled)
+		fn(ctx, u.buffer, port)
+	}()
 
 	for {
-		buf := make([]byte, 2048)
-		n, conn, err := u.server.ReadFromUDP(buf)
+		buf := buffers.Get().(*[BufferSize]byte)
+		n, addr, err := u.server.ReadFromUDPAddrPort(buf[:])
 		if err != nil {
-			log.Println(err)
+			buffers.Put(buf)
+			if !errors.Is(err, net.ErrClosed) {
+				log.Println(err)
+			}
 			break
 		}
-		if conn == nil {
+		if !addr.IsValid() {
+			buffers.Put(buf)
 			log.Println("UDP: no connection")
 			continue
 		}
 
-		udpBuffer <- buf[:n]
+		select {
+		case u.buffer <- Packet{Data: buf[:n], Source: unmap(addr)}:
+		case <-handled:
+			return nil
+		}
 	}
+
+	close(u.buffer)
+	<-handled
 	return nil
 }
 
-// Close ensures that the UDPServer is shut down gracefully.
+// Close stops the UDPServer, Run returns once the handler has processed the received packets.
 func (u *UDPServer) Close() error {
-	return u.server.Close()
+	if u.server == nil {
+		return nil
+	}
+	err := u.server.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// unmap returns the IPv4 address reported as an IPv4-mapped IPv6 address, eg. on a dual stack socket,
+// so the address of a game is the same on every socket
+func unmap(addr netip.AddrPort) netip.AddrPort {
+	return netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
 }
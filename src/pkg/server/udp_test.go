@@ -1,9 +1,14 @@
 package server_test
 
 import (
+	"context"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUdpServer(t *testing.T) {
@@ -12,7 +17,7 @@ func TestUdpServer(t *testing.T) {
 			Addr: "invalid",
 		}
 
-		err := udpServer.Run(func(chan []byte, int) {}, 1234)
+		err := udpServer.Run(context.Background(), func(context.Context, chan server.Packet, int) {}, 1234)
 
 		if err == nil {
 			t.Errorf("Run() error = %v, wantErr %v", err, true)
@@ -24,13 +29,99 @@ func TestUdpServer(t *testing.T) {
 			Addr: ":1234",
 		}
 
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan error)
 		go func() {
-			err := udpServer.Run(func(chan []byte, int) {}, 1234)
-			defer udpServer.Close()
-
-			if err != nil {
-				t.Errorf("Run() error = %v", err)
-			}
+			stopped <- udpServer.Run(ctx, func(context.Context, chan server.Packet, int) {}, 1234)
 		}()
+		cancel()
+
+		if err := <-stopped; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
 	})
 }
+
+func TestUdpServer_Shutdown(t *testing.T) {
+	udpServer := server.NewServer("127.0.0.1:6251")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	received := make(chan server.Packet, 16)
+	processed := make(chan server.Packet, 16)
+	stopped := make(chan error)
+	go func() {
+		stopped <- udpServer.Run(ctx, func(_ context.Context, channel chan server.Packet, _ int) {
+			for packet := range channel {
+				received <- packet
+				// the packet is processed after the shutdown has been requested
+				time.Sleep(50 * time.Millisecond)
+				processed <- packet
+			}
+		}, 6251)
+	}()
+
+	assert.Eventually(t, func() bool {
+		conn, err := net.Dial("udp", "127.0.0.1:6251")
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = conn.Write([]byte("packet"))
+
+		select {
+		case <-received:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+		packet := <-processed
+		assert.Equal(t, "packet", string(packet.Data))
+		assert.Equal(t, "127.0.0.1", packet.Source.Addr().String())
+	case <-time.After(time.Second):
+		t.Fatal("server not stopped")
+	}
+	assert.NoError(t, udpServer.Close())
+}
+
+func BenchmarkUDPServer_Run(b *testing.B) {
+	udpServer := server.NewServer("127.0.0.1:6252")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan struct{})
+	go func() {
+		_ = udpServer.Run(ctx, func(_ context.Context, channel chan server.Packet, _ int) {
+			for packet := range channel {
+				server.ReleaseBuffer(packet.Data)
+				received <- struct{}{}
+			}
+		}, 6252)
+	}()
+
+	conn, err := net.Dial("udp", "127.0.0.1:6252")
+	require.NoError(b, err)
+	defer conn.Close()
+	packet := make([]byte, 331)
+
+	// wait for the server to listen
+	require.Eventually(b, func() bool {
+		_, _ = conn.Write(packet)
+		select {
+		case <-received:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, time.Second, time.Millisecond)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = conn.Write(packet)
+		<-received
+	}
+}
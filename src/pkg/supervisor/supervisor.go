@@ -0,0 +1,101 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+var ErrListenerStopped = errors.New("listener stopped")
+
+// Listener is a single game handler bound to a single port
+type Listener struct {
+	Game    enums.Game
+	Port    int
+	Handler telemetry.TelemetryInterface
+}
+
+// String returns a readable name of the listener, eg. fms2023:9999
+func (l Listener) String() string {
+	return fmt.Sprintf("%s:%d", l.Game, l.Port)
+}
+
+// Supervisor runs every configured listener concurrently.
+// A failing listener is reported and does not stop the others.
+type Supervisor struct {
+	// OnError is called as soon as a listener fails
+	OnError   func(listener Listener, err error)
+	listeners []Listener
+}
+
+// NewSupervisor creates a new Supervisor without listeners
+func NewSupervisor() *Supervisor {
+	return &Supervisor{}
+}
+
+// Add registers a new handler for the game on the given port
+func (s *Supervisor) Add(game enums.Game, port int, handler telemetry.TelemetryInterface) {
+	s.listeners = append(s.listeners, Listener{
+		Game:    game,
+		Port:    port,
+		Handler: handler,
+	})
+}
+
+// Listeners returns the registered listeners
+func (s *Supervisor) Listeners() []Listener {
+	return s.listeners
+}
+
+// Run starts all listeners and blocks until every one of them has stopped.
+// The listeners are stopped when ctx is cancelled. It returns the errors of the failed listeners.
+func (s *Supervisor) Run(ctx context.Context) []error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		report = func(listener Listener, err error) {
+			err = errors.Wrapf(err, "[%s]", listener)
+			log.Println(err)
+
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+
+			if s.OnError != nil {
+				s.OnError(listener, err)
+			}
+		}
+	)
+
+	for _, listener := range s.listeners {
+		wg.Add(1)
+		go func(listener Listener) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					report(listener, errors.Errorf("panic: %v", r))
+				}
+			}()
+
+			log.Printf("[%s] starting listener", listener)
+			err := listener.Handler.InitAndRun(ctx, listener.Port)
+			if err == nil {
+				if ctx.Err() != nil {
+					log.Printf("[%s] listener stopped", listener)
+					return
+				}
+				err = ErrListenerStopped
+			}
+			report(listener, err)
+		}(listener)
+	}
+
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,102 @@
+package supervisor_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/supervisor"
+	"github.com/stretchr/testify/assert"
+)
+
+var errPortInUse = errors.New("could not listen on UDP")
+
+type fakeHandler struct {
+	err     error
+	release chan struct{}
+	ports   chan int
+}
+
+func (f *fakeHandler) InitAndRun(_ context.Context, port int) error {
+	f.ports <- port
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err == nil {
+		panic("listener crashed")
+	}
+	return f.err
+}
+
+func TestSupervisor_Run(t *testing.T) {
+	ports := make(chan int, 3)
+	failing := &fakeHandler{err: errPortInUse, ports: ports}
+	running := &fakeHandler{err: errPortInUse, release: make(chan struct{}), ports: ports}
+	panicking := &fakeHandler{ports: ports}
+
+	sv := supervisor.NewSupervisor()
+	sv.Add(enums.Games.ForzaMotorsport2023(), 9999, failing)
+	sv.Add(enums.Games.ForzaMotorsport2023(), 9998, running)
+	sv.Add(enums.Games.ForzaMotorsport2023(), 9997, panicking)
+
+	var mu sync.Mutex
+	var failed []string
+	twoFailures := make(chan struct{})
+	sv.OnError = func(listener supervisor.Listener, _ error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, listener.String())
+		if len(failed) == 2 {
+			close(twoFailures)
+		}
+	}
+
+	done := make(chan []error)
+	go func() {
+		done <- sv.Run(context.Background())
+	}()
+
+	<-twoFailures
+	assert.ElementsMatch(t, []string{"fms2023:9999", "fms2023:9997"}, failed)
+	select {
+	case <-done:
+		t.Fatal("supervisor stopped while a listener is still running")
+	default:
+	}
+
+	close(running.release)
+	errs := <-done
+
+	assert.Len(t, errs, 3)
+	assert.ElementsMatch(t, []int{9999, 9998, 9997}, []int{<-ports, <-ports, <-ports})
+	for _, err := range errs {
+		if errors.Is(err, errPortInUse) {
+			continue
+		}
+		assert.Contains(t, err.Error(), "[fms2023:9997]: panic: listener crashed")
+	}
+}
+
+type stoppingHandler struct{}
+
+func (stoppingHandler) InitAndRun(ctx context.Context, _ int) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestSupervisor_RunStopsOnCancel(t *testing.T) {
+	sv := supervisor.NewSupervisor()
+	sv.Add(enums.Games.ForzaMotorsport2023(), 9999, stoppingHandler{})
+	sv.Add(enums.Games.F1(), 20777, stoppingHandler{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []error)
+	go func() {
+		done <- sv.Run(ctx)
+	}()
+	cancel()
+
+	assert.Empty(t, <-done)
+}
@@ -0,0 +1,297 @@
+package ac
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"io/fs"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CarInfoFormatFile describes the 328 bytes RTCarInfo packet
+	CarInfoFormatFile = "rtcarinfo"
+	// LapFormatFile describes the 212 bytes RTLap packet
+	LapFormatFile = "rtlap"
+	// FormatsDirEnvKey is the environment variable with the directory of the Assetto Corsa format files
+	FormatsDirEnvKey = "TMD_AC_FORMATS"
+	// HandshakeResponseSize is the size of the response to the handshake
+	HandshakeResponseSize = 408
+	// DefaultPort is the Assetto Corsa UDP port
+	DefaultPort = 9996
+	// DefaultHandshakeTimeout is the time without any data after which the handshake is repeated
+	DefaultHandshakeTimeout = 5 * time.Second
+)
+
+// Operations sent to the game
+const (
+	OperationHandshake       int32 = 0
+	OperationSubscribeUpdate int32 = 1
+	OperationSubscribeSpot   int32 = 2
+	OperationDismiss         int32 = 3
+)
+
+// Subscription modes
+const (
+	// ModeUpdate subscribes to the RTCarInfo packets sent every physics step
+	ModeUpdate = "update"
+	// ModeSpot subscribes to the RTLap packets sent on every completed lap
+	ModeSpot = "spot"
+)
+
+var (
+	ErrInvalidMode   = errors.New("[AC] invalid subscription mode")
+	ErrUnknownPacket = errors.WithMessage(telemetry.ErrUnknownFormat, "[AC]")
+)
+
+//go:embed rtcarinfo rtlap
+var formatFiles embed.FS
+
+// HandshakeResponse is sent by the game as the response to the handshake
+type HandshakeResponse struct {
+	CarName     string
+	DriverName  string
+	Identifier  int32
+	Version     int32
+	TrackName   string
+	TrackConfig string
+}
+
+// AssettoCorsaHandler connects to Assetto Corsa, performs the handshake and subscribes to the updates.
+type AssettoCorsaHandler struct {
+	telemetry.TelemetryHandler
+	DebugMode        string
+	Host             string
+	Mode             string
+	HandshakeTimeout time.Duration
+	// CarInfoKeys and LapKeys contain every channel published to the adapters
+	CarInfoKeys []string
+	LapKeys     []string
+	Session     HandshakeResponse
+	client      server.Client
+	carInfo     *telemetry.Schema
+	lap         *telemetry.Schema
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// NewAssettoCorsaHandler creates a new AssettoCorsaHandler connecting to the game host, the mode defaults to update
+func NewAssettoCorsaHandler(host, mode, debugMode string) *AssettoCorsaHandler {
+	if mode == "" {
+		mode = ModeUpdate
+	}
+	return &AssettoCorsaHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.AssettoCorsa()),
+		},
+		DebugMode:        debugMode,
+		Host:             host,
+		Mode:             mode,
+		HandshakeTimeout: DefaultHandshakeTimeout,
+	}
+}
+
+// InitAndRun connects to the game on the given port
+func (ac *AssettoCorsaHandler) InitAndRun(ctx context.Context, port int) error {
+	if ac.Mode != ModeUpdate && ac.Mode != ModeSpot {
+		return errors.Wrapf(ErrInvalidMode, "%q", ac.Mode)
+	}
+
+	err := ac.LoadFormats()
+	if err != nil {
+		return err
+	}
+
+	ac.client = server.NewClient(net.JoinHostPort(ac.Host, strconv.Itoa(port)))
+
+	log.Printf("Assetto Corsa client connecting to %s:%d...\n", ac.Host, port)
+
+	return ac.client.Run(ctx, ac.ProcessChannel, port)
+}
+
+// LoadFormats loads the packet formats and builds the lists of published channels
+func (ac *AssettoCorsaHandler) LoadFormats() error {
+	var err error
+	ac.carInfo, err = telemetry.LoadSchema(FormatsFS(), CarInfoFormatFile)
+	if err != nil {
+		return err
+	}
+	ac.lap, err = telemetry.LoadSchema(FormatsFS(), LapFormatFile)
+	if err != nil {
+		return err
+	}
+
+	ac.CarInfoKeys = append([]string{"IsRaceOn"}, ac.carInfo.Keys...)
+	ac.LapKeys = ac.lap.Keys
+	return nil
+}
+
+// ProcessChannel performs the handshake and processes the received packets.
+// When ctx is cancelled the game is dismissed before the connection is closed.
+func (ac *AssettoCorsaHandler) ProcessChannel(ctx context.Context, channel chan server.Packet, port int) {
+	defer ac.StartBus(port).Close()
+
+	ac.send(OperationHandshake)
+	timeout := time.NewTimer(ac.HandshakeTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ac.send(OperationDismiss)
+			return
+		case packet := <-channel:
+			timeout.Reset(ac.HandshakeTimeout)
+			ac.Process(packet, port, ac.ProcessBuffer)
+		case <-timeout.C:
+			// the game was not running or has been restarted
+			telemetry.DisplayLog("vvv", "Assetto Corsa is not responding, repeating the handshake")
+			ac.send(OperationHandshake)
+			timeout.Reset(ac.HandshakeTimeout)
+		}
+	}
+}
+
+// ProcessBuffer processes the received data, the packet type is recognized by the packet length
+func (ac *AssettoCorsaHandler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	switch len(buffer) {
+	case HandshakeResponseSize:
+		ac.Session = ParseHandshakeResponse(buffer)
+		log.Printf(
+			"[%s] Connected, driver %s in %s on %s %s\n",
+			enums.Games.AssettoCorsa(), ac.Session.DriverName, ac.Session.CarName,
+			ac.Session.TrackName, ac.Session.TrackConfig,
+		)
+
+		operation := OperationSubscribeUpdate
+		if ac.Mode == ModeSpot {
+			operation = OperationSubscribeSpot
+		}
+		ac.send(operation)
+		server.ReleaseBuffer(buffer)
+	case ac.carInfo.Size:
+		decoded := ac.samples.Get()
+		decoded.Buffer = buffer
+		ac.carInfo.DecodeValuesInto(buffer, decoded.Values)
+		values := decoded.Data
+		telemetry.FloatsInto(decoded.Values, values)
+		values["IsRaceOn"] = 1
+		decoded.Normalized = normalize(values)
+
+		published := decoded.GameData(ac.CarInfoKeys, packet.Source)
+		published.Normalized = &decoded.Normalized
+		ac.Bus.Publish(published)
+	case ac.lap.Size:
+		driverName := decodeString(buffer[8:108])
+		telemetry.DisplayLog("vvv", "Lap completed by "+driverName+" in "+decodeString(buffer[108:208]))
+		decoded := ac.samples.Get()
+		decoded.Buffer = buffer
+		ac.lap.DecodeValuesInto(buffer, decoded.Values)
+		telemetry.FloatsInto(decoded.Values, decoded.Data)
+		decoded.Normalized = normalizeLap(decoded.Data, driverName == ac.Session.DriverName)
+
+		published := decoded.GameData(ac.LapKeys, packet.Source)
+		published.Normalized = &decoded.Normalized
+		ac.Bus.Publish(published)
+	default:
+		return errors.Wrapf(ErrUnknownPacket, "%d bytes", len(buffer))
+	}
+	return nil
+}
+
+// normalize maps the car info values to the normalized sample.
+// The game sends the reverse as the gear 0 and the neutral as the gear 1.
+func normalize(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn: values["IsRaceOn"] != 0,
+		IsPlayer: true,
+		Speed:    values["SpeedMs"],
+		RPM:      values["EngineRPM"],
+		Gear:     int(values["Gear"]) - 1,
+		Throttle: values["Gas"],
+		Brake:    values["Brake"],
+		Clutch:   values["Clutch"],
+		Position: telemetry.VectorOf(values, "CarCoordinates"),
+		Acceleration: telemetry.Vector3{
+			X: values["AccGHorizontal"] * telemetry.StandardGravity,
+			Y: values["AccGVertical"] * telemetry.StandardGravity,
+			Z: values["AccGFrontal"] * telemetry.StandardGravity,
+		},
+		WheelSpeed:       telemetry.WheelsOf(values, "WheelAngularSpeed"),
+		SuspensionTravel: telemetry.WheelsOf(values, "SuspensionHeight"),
+		LapNumber:        int(values["LapCount"]),
+		CurrentLap:       values["LapTime"] / 1000,
+		LastLap:          values["LastLap"] / 1000,
+		BestLap:          values["BestLap"] / 1000,
+	}
+}
+
+// normalizeLap maps the lap values to the normalized sample.
+// The laps of every car are sent, the player is recognized by the driver name of the handshake.
+func normalizeLap(values map[string]float32, isPlayer bool) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn:  true,
+		IsPlayer:  isPlayer,
+		LapNumber: int(values["Lap"]),
+		LastLap:   values["Time"] / 1000,
+		CarID:     int(values["CarIdentifierNumber"]),
+	}
+}
+
+// send sends the operation request to the game
+func (ac *AssettoCorsaHandler) send(operation int32) {
+	err := ac.client.Send(Request(operation))
+	if err != nil {
+		telemetry.DisplayLog("vvv", err)
+	}
+}
+
+// Request builds the handshake request for the operation
+func Request(operation int32) []byte {
+	request := make([]byte, 12)
+	binary.LittleEndian.PutUint32(request[0:4], 1) // identifier, the device type
+	binary.LittleEndian.PutUint32(request[4:8], 1) // version
+	binary.LittleEndian.PutUint32(request[8:12], uint32(operation))
+	return request
+}
+
+// ParseHandshakeResponse reads the car, driver and track sent in the handshake response
+func ParseHandshakeResponse(buffer []byte) HandshakeResponse {
+	return HandshakeResponse{
+		CarName:     decodeString(buffer[0:100]),
+		DriverName:  decodeString(buffer[100:200]),
+		Identifier:  int32(binary.LittleEndian.Uint32(buffer[200:204])),
+		Version:     int32(binary.LittleEndian.Uint32(buffer[204:208])),
+		TrackName:   decodeString(buffer[208:308]),
+		TrackConfig: decodeString(buffer[308:408]),
+	}
+}
+
+// decodeString decodes the UTF-16 string, the game terminates the strings with "%" or a null character
+func decodeString(buffer []byte) string {
+	characters := make([]uint16, len(buffer)/2)
+	for i := range characters {
+		characters[i] = binary.LittleEndian.Uint16(buffer[i*2:])
+	}
+	decoded := string(utf16.Decode(characters))
+	if end := strings.IndexAny(decoded, "%\x00"); end >= 0 {
+		return decoded[:end]
+	}
+	return decoded
+}
+
+// FormatsFS returns the Assetto Corsa format files, TMD_AC_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
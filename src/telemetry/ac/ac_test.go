@@ -0,0 +1,175 @@
+package ac_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/ac"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHandshakeResponse(t *testing.T) {
+	response := ac.ParseHandshakeResponse(handshakeResponse())
+
+	assert.Equal(t, ac.HandshakeResponse{
+		CarName:     "ks_mazda_mx5_cup",
+		DriverName:  "Driver",
+		Identifier:  4242,
+		Version:     1,
+		TrackName:   "magione",
+		TrackConfig: "",
+	}, response)
+}
+
+func TestAssettoCorsaHandler_InvalidMode(t *testing.T) {
+	handler := ac.NewAssettoCorsaHandler("127.0.0.1", "replay", "")
+
+	assert.ErrorIs(t, handler.InitAndRun(context.Background(), ac.DefaultPort), ac.ErrInvalidMode)
+}
+
+func TestAssettoCorsaHandler_FakeGame(t *testing.T) {
+	game, operations := fakeGame(t, ac.OperationSubscribeUpdate, carInfo())
+
+	adapter := &test.RecordingAdapter{}
+	handler := ac.NewAssettoCorsaHandler("127.0.0.1", "", "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- handler.InitAndRun(ctx, game.LocalAddr().(*net.UDPAddr).Port)
+	}()
+
+	require.Eventually(t, func() bool { return adapter.Count() > 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ac.OperationHandshake, <-operations)
+	assert.Equal(t, ac.OperationSubscribeUpdate, <-operations)
+
+	data := adapter.First()
+	assert.Equal(t, handler.CarInfoKeys, data.Keys)
+	assert.Equal(t, float32(1), data.Data["IsRaceOn"])
+	assert.Equal(t, float32(123.5), data.Data["SpeedKmh"])
+	assert.Equal(t, float32(7250), data.Data["EngineRPM"])
+	assert.Equal(t, float32(3), data.Data["Gear"])
+	assert.Equal(t, float32(1), data.Data["IsTcEnabled"])
+	assert.Equal(t, float32(0.5), data.Data["CarPositionNormalized"])
+
+	require.NotNil(t, data.Normalized)
+	assert.Equal(t, float32(7250), data.Normalized.RPM)
+	assert.Equal(t, 2, data.Normalized.Gear)
+
+	// the game is dismissed on shutdown
+	cancel()
+	require.NoError(t, <-stopped)
+	assert.Eventually(t, func() bool {
+		return len(operations) > 0 && <-operations == ac.OperationDismiss
+	}, time.Second, time.Millisecond)
+}
+
+func TestAssettoCorsaHandler_Spot(t *testing.T) {
+	game, operations := fakeGame(t, ac.OperationSubscribeSpot, lap("Driver%", 7, 3, 95123), lap("Other%", 8, 4, 94000))
+
+	adapter := &test.RecordingAdapter{}
+	handler := ac.NewAssettoCorsaHandler("127.0.0.1", ac.ModeSpot, "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = handler.InitAndRun(ctx, game.LocalAddr().(*net.UDPAddr).Port)
+	}()
+
+	require.Eventually(t, func() bool { return adapter.Count() == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ac.OperationHandshake, <-operations)
+	assert.Equal(t, ac.OperationSubscribeSpot, <-operations)
+
+	player := adapter.First()
+	assert.Equal(t, handler.LapKeys, player.Keys)
+	assert.Equal(t, float32(95123), player.Data["Time"])
+	require.NotNil(t, player.Normalized)
+	assert.Equal(t, telemetry.Normalized{IsRaceOn: true, IsPlayer: true, LapNumber: 3, LastLap: 95.123, CarID: 7},
+		*player.Normalized)
+
+	other := adapter.All()[1]
+	require.NotNil(t, other.Normalized)
+	assert.False(t, other.Normalized.IsPlayer, "the laps of the other drivers are not the player laps")
+	assert.Equal(t, 8, other.Normalized.CarID)
+}
+
+// fakeGame answers the handshake and sends the packets after the subscription operation
+func fakeGame(t *testing.T, subscription int32, packets ...[]byte) (*net.UDPConn, chan int32) {
+	game, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = game.Close() })
+
+	operations := make(chan int32, 10)
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			n, addr, err := game.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if n != 12 {
+				continue
+			}
+			operation := int32(binary.LittleEndian.Uint32(buffer[8:12]))
+			operations <- operation
+			switch operation {
+			case ac.OperationHandshake:
+				_, _ = game.WriteToUDP(handshakeResponse(), addr)
+			case subscription:
+				for _, packet := range packets {
+					_, _ = game.WriteToUDP(packet, addr)
+				}
+			}
+		}
+	}()
+	return game, operations
+}
+
+func handshakeResponse() []byte {
+	response := make([]byte, ac.HandshakeResponseSize)
+	putString(response[0:100], "ks_mazda_mx5_cup%")
+	putString(response[100:200], "Driver%")
+	binary.LittleEndian.PutUint32(response[200:204], 4242)
+	binary.LittleEndian.PutUint32(response[204:208], 1)
+	putString(response[208:308], "magione%")
+	putString(response[308:408], "%")
+	return response
+}
+
+func carInfo() []byte {
+	packet := make([]byte, 328)
+	packet[0] = 'a'
+	binary.LittleEndian.PutUint32(packet[4:], 328)
+	binary.LittleEndian.PutUint32(packet[8:], math.Float32bits(123.5))
+	packet[23] = 1 // IsTcEnabled
+	binary.LittleEndian.PutUint32(packet[68:], math.Float32bits(7250))
+	binary.LittleEndian.PutUint32(packet[76:], 3)
+	binary.LittleEndian.PutUint32(packet[308:], math.Float32bits(0.5))
+	return packet
+}
+
+func lap(driverName string, car, lap, timeMs uint32) []byte {
+	packet := make([]byte, 212)
+	binary.LittleEndian.PutUint32(packet[0:], car)
+	binary.LittleEndian.PutUint32(packet[4:], lap)
+	putString(packet[8:108], driverName)
+	putString(packet[108:208], "ks_mazda_mx5_cup%")
+	binary.LittleEndian.PutUint32(packet[208:], timeMs)
+	return packet
+}
+
+func putString(buffer []byte, value string) {
+	for i, character := range utf16.Encode([]rune(value)) {
+		binary.LittleEndian.PutUint16(buffer[i*2:], character)
+	}
+}
@@ -0,0 +1,352 @@
+package acc
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort is the broadcasting port set in the ACC broadcasting.json
+	DefaultPort = 9000
+	// DisplayName is the name of the application shown by ACC
+	DisplayName = "simracing-telemetry"
+	// DefaultUpdateInterval is the interval of the realtime updates requested from ACC
+	DefaultUpdateInterval = 250 * time.Millisecond
+	// DefaultConnectionTimeout is the time without any data after which the application registers again
+	DefaultConnectionTimeout = 5 * time.Second
+	// entryListRequestInterval limits the entry list requests when the cars join the session
+	entryListRequestInterval = time.Second
+)
+
+var ErrRegistrationFailed = errors.New("[ACC] registration failed")
+
+// splitKeys are the channels of the last lap sector times
+var splitKeys = [3]string{"LastLapSplit1", "LastLapSplit2", "LastLapSplit3"}
+
+// Keys contains every channel published to the adapters, one GameData is published for every car
+var Keys = []string{
+	"IsRaceOn",
+	"SessionType",
+	"SessionPhase",
+	"SessionTime",
+	"SessionEndTime",
+	"AmbientTemp",
+	"TrackTemp",
+	"FocusedCarIndex",
+	"CarIndex",
+	"RaceNumber",
+	"CarModelType",
+	"CupCategory",
+	"DriverIndex",
+	"Gear",
+	"WorldPosX",
+	"WorldPosY",
+	"Yaw",
+	"CarLocation",
+	"Kmh",
+	"Position",
+	"CupPosition",
+	"TrackPosition",
+	"SplinePosition",
+	"Laps",
+	"Delta",
+	"BestSessionLap",
+	"LastLap",
+	"LastLapSplit1",
+	"LastLapSplit2",
+	"LastLapSplit3",
+	"LastLapIsInvalid",
+	"CurrentLap",
+	"CurrentLapIsInvalid",
+}
+
+// ACCHandler registers to the Assetto Corsa Competizione broadcasting API and publishes
+// the timing and position of every car in the session.
+type ACCHandler struct {
+	telemetry.TelemetryHandler
+	DebugMode          string
+	Host               string
+	ConnectionPassword string
+	CommandPassword    string
+	UpdateInterval     time.Duration
+	ConnectionTimeout  time.Duration
+	// Cars is the entry list, the car is nil until its details are received
+	Cars              map[uint16]*Car
+	Track             Track
+	Session           Session
+	connectionID      int32
+	registered        bool
+	lastEntryListSent time.Time
+	client            server.Client
+	// samples contains the published samples released by the adapters, reused for the next car updates
+	samples telemetry.SamplePool
+}
+
+// NewACCHandler creates a new ACCHandler connecting to the broadcasting API of the game host
+func NewACCHandler(host, connectionPassword, debugMode string) *ACCHandler {
+	return &ACCHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.AssettoCorsaCompetizione()),
+		},
+		DebugMode:          debugMode,
+		Host:               host,
+		ConnectionPassword: connectionPassword,
+		UpdateInterval:     DefaultUpdateInterval,
+		ConnectionTimeout:  DefaultConnectionTimeout,
+		Cars:               map[uint16]*Car{},
+	}
+}
+
+// InitAndRun connects to the broadcasting API on the given port
+func (acc *ACCHandler) InitAndRun(ctx context.Context, port int) error {
+	acc.client = server.NewClient(net.JoinHostPort(acc.Host, strconv.Itoa(port)))
+
+	log.Printf("ACC client connecting to %s:%d...\n", acc.Host, port)
+
+	return acc.client.Run(ctx, acc.ProcessChannel, port)
+}
+
+// ProcessChannel registers to the broadcasting API and processes the received messages.
+// When ctx is cancelled the application is unregistered before the connection is closed.
+func (acc *ACCHandler) ProcessChannel(ctx context.Context, channel chan server.Packet, port int) {
+	defer acc.StartBus(port).Close()
+
+	acc.register()
+	timeout := time.NewTimer(acc.ConnectionTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			acc.unregister()
+			return
+		case packet := <-channel:
+			timeout.Reset(acc.ConnectionTimeout)
+			acc.Process(packet, port, acc.ProcessBuffer)
+		case <-timeout.C:
+			// the game was not running, has been restarted or the session has changed
+			telemetry.DisplayLog("vvv", "ACC is not responding, registering again")
+			acc.register()
+			timeout.Reset(acc.ConnectionTimeout)
+		}
+	}
+}
+
+// ProcessBuffer processes the received message, the first byte is the message type.
+// The messages are parsed into their own structures, so the buffer is reused once the message is processed.
+func (acc *ACCHandler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	if len(buffer) == 0 {
+		return ErrMessageTooShort
+	}
+
+	switch buffer[0] {
+	case RegistrationResult:
+		registration, err := ParseRegistration(buffer)
+		if err != nil {
+			return err
+		}
+		if !registration.Success {
+			return errors.Wrap(ErrRegistrationFailed, registration.ErrorMessage)
+		}
+		acc.connectionID = registration.ConnectionID
+		acc.registered = true
+		log.Printf("[%s] Registered, connection %d\n", enums.Games.AssettoCorsaCompetizione(), acc.connectionID)
+
+		acc.send(ConnectionRequest(RequestEntryList, acc.connectionID))
+		acc.send(ConnectionRequest(RequestTrackData, acc.connectionID))
+	case RealtimeUpdate:
+		session, err := ParseSession(buffer)
+		if err != nil {
+			return err
+		}
+		acc.Session = session
+	case RealtimeCarUpdate:
+		update, err := ParseCarUpdate(buffer)
+		if err != nil {
+			return err
+		}
+		car, ok := acc.Cars[update.CarIndex]
+		if !ok || car == nil || len(car.Drivers) != int(update.DriverCount) {
+			// a new car has joined the session or the entry list is not complete yet
+			acc.requestEntryList()
+		}
+		acc.publish(update, car, packet.Source)
+	case EntryList:
+		indexes, err := ParseEntryList(buffer)
+		if err != nil {
+			return err
+		}
+		acc.Cars = make(map[uint16]*Car, len(indexes))
+		for _, index := range indexes {
+			acc.Cars[index] = nil
+		}
+	case EntryListCar:
+		car, err := ParseEntryListCar(buffer)
+		if err != nil {
+			return err
+		}
+		acc.Cars[car.CarIndex] = &car
+		telemetry.DisplayLog("vvv", "Car #"+strconv.Itoa(int(car.RaceNumber))+" "+car.TeamName+" "+driverNames(car))
+	case TrackData:
+		track, err := ParseTrack(buffer)
+		if err != nil {
+			return err
+		}
+		acc.Track = track
+		log.Printf("[%s] Track %s, %dm\n", enums.Games.AssettoCorsaCompetizione(), track.Name, track.Meters)
+	case BroadcastingEvent:
+		event, err := ParseEvent(buffer)
+		if err != nil {
+			return err
+		}
+		telemetry.DisplayLog("vvv", "Event "+strconv.Itoa(int(event.Type))+": "+event.Message)
+	default:
+		return errors.Wrapf(ErrUnknownMessage, "type %d", buffer[0])
+	}
+
+	server.ReleaseBuffer(buffer)
+	return nil
+}
+
+// publish sends the car update merged with the session and the entry list to the adapters,
+// IsRaceOn is calculated from the session phase so it is only in Data
+func (acc *ACCHandler) publish(update CarUpdate, car *Car, source netip.AddrPort) {
+	decoded := acc.samples.Get()
+	values := decoded.Values
+	values["SessionType"] = intValue("U8", acc.Session.SessionType)
+	values["SessionPhase"] = intValue("U8", acc.Session.Phase)
+	values["SessionTime"] = floatValue(acc.Session.SessionTimeMs)
+	values["SessionEndTime"] = floatValue(acc.Session.SessionEndTimeMs)
+	values["AmbientTemp"] = intValue("U8", acc.Session.AmbientTemp)
+	values["TrackTemp"] = intValue("U8", acc.Session.TrackTemp)
+	values["FocusedCarIndex"] = intValue("S32", acc.Session.FocusedCarIndex)
+	values["CarIndex"] = intValue("U16", update.CarIndex)
+	values["DriverIndex"] = intValue("U16", update.DriverIndex)
+	values["Gear"] = intValue("S8", update.Gear)
+	values["WorldPosX"] = floatValue(update.WorldPosX)
+	values["WorldPosY"] = floatValue(update.WorldPosY)
+	values["Yaw"] = floatValue(update.Yaw)
+	values["CarLocation"] = intValue("U8", update.CarLocation)
+	values["Kmh"] = intValue("U16", update.Kmh)
+	values["Position"] = intValue("U16", update.Position)
+	values["CupPosition"] = intValue("U16", update.CupPosition)
+	values["TrackPosition"] = intValue("U16", update.TrackPosition)
+	values["SplinePosition"] = floatValue(update.SplinePosition)
+	values["Laps"] = intValue("U16", update.Laps)
+	values["Delta"] = intValue("S32", update.DeltaMs)
+	values["BestSessionLap"] = intValue("S32", update.BestSessionLap.LapTimeMs)
+	values["LastLap"] = intValue("S32", update.LastLap.LapTimeMs)
+	values["LastLapIsInvalid"] = intValue("U8", boolValue(update.LastLap.IsInvalid))
+	values["CurrentLap"] = intValue("S32", update.CurrentLap.LapTimeMs)
+	values["CurrentLapIsInvalid"] = intValue("U8", boolValue(update.CurrentLap.IsInvalid))
+	for i, key := range splitKeys {
+		split := int32(-1)
+		if i < len(update.LastLap.Splits) {
+			split = update.LastLap.Splits[i]
+		}
+		values[key] = intValue("S32", split)
+	}
+	if car != nil {
+		values["RaceNumber"] = intValue("S32", car.RaceNumber)
+		values["CarModelType"] = intValue("U8", car.CarModelType)
+		values["CupCategory"] = intValue("U8", car.CupCategory)
+	}
+
+	data := decoded.Data
+	telemetry.FloatsInto(values, data)
+	data["IsRaceOn"] = 0
+	if acc.Session.Phase >= PhaseFormationLap && acc.Session.Phase <= PhaseSessionOver {
+		data["IsRaceOn"] = 1
+	}
+	decoded.Normalized = normalize(data)
+
+	published := decoded.GameData(Keys, source)
+	published.Normalized = &decoded.Normalized
+	acc.Bus.Publish(published)
+}
+
+// normalize maps the car values to the normalized sample, the car followed by the camera is the player car.
+// The game sends only the position on the track map, without the height.
+func normalize(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn:     values["IsRaceOn"] != 0,
+		IsPlayer:     values["CarIndex"] == values["FocusedCarIndex"],
+		Speed:        values["Kmh"] / 3.6,
+		Gear:         int(values["Gear"]),
+		Position:     telemetry.Vector3{X: values["WorldPosX"], Z: values["WorldPosY"]},
+		Yaw:          values["Yaw"],
+		LapNumber:    int(values["Laps"]),
+		RacePosition: int(values["Position"]),
+		CurrentLap:   max(values["CurrentLap"], 0) / 1000,
+		LastLap:      max(values["LastLap"], 0) / 1000,
+		BestLap:      max(values["BestSessionLap"], 0) / 1000,
+		CarID:        int(values["CarModelType"]),
+	}
+}
+
+// register sends the register command application request
+func (acc *ACCHandler) register() {
+	acc.registered = false
+	acc.send(RegisterRequest(
+		DisplayName, acc.ConnectionPassword, int32(acc.UpdateInterval.Milliseconds()), acc.CommandPassword,
+	))
+}
+
+// unregister sends the unregister request when the application has been registered
+func (acc *ACCHandler) unregister() {
+	if acc.registered {
+		acc.send(ConnectionRequest(UnregisterCommandApplication, acc.connectionID))
+	}
+}
+
+// requestEntryList requests the entry list, at most once per entryListRequestInterval
+func (acc *ACCHandler) requestEntryList() {
+	if !acc.registered || time.Since(acc.lastEntryListSent) < entryListRequestInterval {
+		return
+	}
+	acc.lastEntryListSent = time.Now()
+	acc.send(ConnectionRequest(RequestEntryList, acc.connectionID))
+}
+
+func (acc *ACCHandler) send(request []byte) {
+	err := acc.client.Send(request)
+	if err != nil {
+		telemetry.DisplayLog("vvv", err)
+	}
+}
+
+func driverNames(car Car) string {
+	names := make([]string, len(car.Drivers))
+	for i, driver := range car.Drivers {
+		names[i] = driver.FirstName + " " + driver.LastName
+	}
+	return strings.Join(names, ", ")
+}
+
+// intValue returns the integer field in its native type
+func intValue[T ~int8 | ~uint8 | ~uint16 | ~int32](dataType string, value T) telemetry.Value {
+	return telemetry.Value{DataType: dataType, Int: int64(value)}
+}
+
+// floatValue returns the float field in its native type
+func floatValue(value float32) telemetry.Value {
+	return telemetry.Value{DataType: "F32", Float: float64(value)}
+}
+
+func boolValue(value bool) uint8 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,211 @@
+package acc_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/acc"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterRequest(t *testing.T) {
+	request := acc.RegisterRequest("app", "asd", 250, "")
+
+	assert.Equal(t, []byte{
+		acc.RegisterCommandApplication, acc.BroadcastingProtocolVersion,
+		3, 0, 'a', 'p', 'p',
+		3, 0, 'a', 's', 'd',
+		250, 0, 0, 0,
+		0, 0,
+	}, request)
+}
+
+func TestParseCarUpdate(t *testing.T) {
+	update, err := acc.ParseCarUpdate(carUpdate())
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(7), update.CarIndex)
+	assert.Equal(t, int8(3), update.Gear)
+	assert.Equal(t, uint16(187), update.Kmh)
+	assert.Equal(t, uint16(2), update.Position)
+	assert.Equal(t, float32(0.25), update.SplinePosition)
+	assert.Equal(t, int32(-1), update.BestSessionLap.LapTimeMs)
+	assert.Equal(t, int32(101234), update.LastLap.LapTimeMs)
+	assert.Equal(t, []int32{30000, 40000, 31234}, update.LastLap.Splits)
+	assert.True(t, update.CurrentLap.IsInvalid)
+
+	_, err = acc.ParseCarUpdate(carUpdate()[:20])
+	assert.ErrorIs(t, err, acc.ErrMessageTooShort)
+}
+
+func TestParseEntryListCar(t *testing.T) {
+	car, err := acc.ParseEntryListCar(entryListCar())
+	require.NoError(t, err)
+
+	assert.Equal(t, acc.Car{
+		CarIndex:     7,
+		CarModelType: 20,
+		TeamName:     "Team",
+		RaceNumber:   88,
+		CupCategory:  1,
+		Nationality:  2,
+		Drivers: []acc.Driver{
+			{FirstName: "First", LastName: "Driver", ShortName: "FDR", Category: 3, Nationality: 2},
+		},
+	}, car)
+}
+
+func TestACCHandler_RegistrationFailed(t *testing.T) {
+	handler := acc.NewACCHandler("127.0.0.1", "wrong", "")
+
+	registration := message(acc.RegistrationResult).int32(-1).byte(0).byte(0).string("wrong password").bytes()
+	err := handler.ProcessBuffer(server.Packet{Data: registration}, 9000)
+
+	assert.ErrorIs(t, err, acc.ErrRegistrationFailed)
+}
+
+func TestACCHandler_FakeGame(t *testing.T) {
+	game, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer game.Close()
+
+	// the fake game accepts the registration, sends the entry list on request and the realtime updates
+	passwords := make(chan string, 10)
+	go func() {
+		buffer := make([]byte, 256)
+		for {
+			n, addr, err := game.ReadFromUDP(buffer)
+			if err != nil || n == 0 {
+				return
+			}
+			switch buffer[0] {
+			case acc.RegisterCommandApplication:
+				// the password follows the display name
+				offset := 4 + len(acc.DisplayName)
+				passwords <- string(buffer[offset+2 : offset+2+int(binary.LittleEndian.Uint16(buffer[offset:]))])
+				result := message(acc.RegistrationResult).int32(12).byte(1).byte(1).string("")
+				_, _ = game.WriteToUDP(result.bytes(), addr)
+			case acc.RequestEntryList:
+				_, _ = game.WriteToUDP(message(acc.EntryList).int32(12).uint16(1).uint16(7).bytes(), addr)
+				_, _ = game.WriteToUDP(entryListCar(), addr)
+				_, _ = game.WriteToUDP(realtimeUpdate(), addr)
+				_, _ = game.WriteToUDP(carUpdate(), addr)
+			}
+		}
+	}()
+
+	adapter := &test.RecordingAdapter{}
+	handler := acc.NewACCHandler("127.0.0.1", "asd", "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+
+	go func() {
+		_ = handler.InitAndRun(context.Background(), game.LocalAddr().(*net.UDPAddr).Port)
+	}()
+
+	require.Eventually(t, func() bool { return adapter.Count() > 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "asd", <-passwords)
+
+	data := adapter.First()
+	assert.Equal(t, acc.Keys, data.Keys)
+	assert.Equal(t, float32(1), data.Data["IsRaceOn"])
+	assert.Equal(t, float32(7), data.Data["FocusedCarIndex"])
+	assert.Equal(t, float32(7), data.Data["CarIndex"])
+	assert.Equal(t, float32(88), data.Data["RaceNumber"])
+	assert.Equal(t, float32(2), data.Data["Position"])
+	assert.Equal(t, float32(101234), data.Data["LastLap"])
+	assert.Equal(t, float32(40000), data.Data["LastLapSplit2"])
+	assert.Equal(t, float32(-1), data.Data["BestSessionLap"])
+	assert.Equal(t, float32(123456), data.Data["SessionTime"])
+	assert.Equal(t, telemetry.Value{DataType: "S32", Int: 101234}, data.Values["LastLap"])
+	assert.Equal(t, telemetry.Value{DataType: "S32", Int: -1}, data.Values["BestSessionLap"])
+	assert.Equal(t, telemetry.Value{DataType: "U16", Int: 7}, data.Values["CarIndex"])
+	assert.NotContains(t, data.Values, "IsRaceOn", "the calculated channels are only in Data")
+
+	require.NotNil(t, data.Normalized)
+	assert.True(t, data.Normalized.IsPlayer)
+	assert.Equal(t, 2, data.Normalized.RacePosition)
+	assert.InDelta(t, 101.234, data.Normalized.LastLap, 0.001)
+}
+
+func realtimeUpdate() []byte {
+	return message(acc.RealtimeUpdate).
+		uint16(1).uint16(2).byte(10).byte(acc.PhaseSession).
+		float32(123456).float32(3600000).int32(7).
+		string("set").string("camera").string("hud").byte(0).
+		float32(50000).byte(22).byte(31).byte(0).byte(0).byte(0).
+		lap(-1, nil, false).
+		bytes()
+}
+
+func carUpdate() []byte {
+	return message(acc.RealtimeCarUpdate).
+		uint16(7).uint16(0).byte(1).byte(5).
+		float32(10).float32(20).float32(0.5).byte(1).
+		uint16(187).uint16(2).uint16(2).uint16(3).float32(0.25).uint16(4).int32(-350).
+		lap(-1, nil, false).
+		lap(101234, []int32{30000, 40000, 31234}, false).
+		lap(45000, []int32{30100}, true).
+		bytes()
+}
+
+func entryListCar() []byte {
+	return message(acc.EntryListCar).
+		uint16(7).byte(20).string("Team").int32(88).byte(1).byte(0).uint16(2).
+		byte(1).string("First").string("Driver").string("FDR").byte(3).uint16(2).
+		bytes()
+}
+
+// messageWriter builds the messages sent by the fake game
+type messageWriter []byte
+
+func message(messageType byte) messageWriter {
+	return messageWriter{messageType}
+}
+
+func (m messageWriter) byte(value byte) messageWriter {
+	return append(m, value)
+}
+
+func (m messageWriter) uint16(value uint16) messageWriter {
+	return binary.LittleEndian.AppendUint16(m, value)
+}
+
+func (m messageWriter) int32(value int32) messageWriter {
+	return binary.LittleEndian.AppendUint32(m, uint32(value))
+}
+
+func (m messageWriter) float32(value float32) messageWriter {
+	return binary.LittleEndian.AppendUint32(m, math.Float32bits(value))
+}
+
+func (m messageWriter) string(value string) messageWriter {
+	return append(m.uint16(uint16(len(value))), value...)
+}
+
+// lap writes the lap, -1 is written as the missing time
+func (m messageWriter) lap(lapTimeMs int32, splits []int32, isInvalid bool) messageWriter {
+	if lapTimeMs < 0 {
+		lapTimeMs = math.MaxInt32
+	}
+	m = m.int32(lapTimeMs).uint16(7).uint16(0).byte(byte(len(splits)))
+	for _, split := range splits {
+		m = m.int32(split)
+	}
+	invalid := byte(0)
+	if isInvalid {
+		invalid = 1
+	}
+	return m.byte(invalid).byte(1).byte(0).byte(0)
+}
+
+func (m messageWriter) bytes() []byte {
+	return m
+}
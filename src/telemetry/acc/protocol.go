@@ -0,0 +1,390 @@
+package acc
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+// BroadcastingProtocolVersion is the version of the ACC broadcasting protocol
+const BroadcastingProtocolVersion = 4
+
+// Outbound message types
+const (
+	RegisterCommandApplication   byte = 1
+	UnregisterCommandApplication byte = 9
+	RequestEntryList             byte = 10
+	RequestTrackData             byte = 11
+)
+
+// Inbound message types
+const (
+	RegistrationResult byte = 1
+	RealtimeUpdate     byte = 2
+	RealtimeCarUpdate  byte = 3
+	EntryList          byte = 4
+	TrackData          byte = 5
+	EntryListCar       byte = 6
+	BroadcastingEvent  byte = 7
+)
+
+// Session phases
+const (
+	PhaseNone byte = iota
+	PhaseStarting
+	PhasePreFormation
+	PhaseFormationLap
+	PhasePreSession
+	PhaseSession
+	PhaseSessionOver
+	PhasePostSession
+	PhaseResultUI
+)
+
+// noTime is sent instead of the lap and split times which are not set yet
+const noTime = math.MaxInt32
+
+var (
+	ErrMessageTooShort = errors.WithMessage(telemetry.ErrPacketTooShort, "[ACC]")
+	ErrUnknownMessage  = errors.WithMessage(telemetry.ErrUnknownFormat, "[ACC]")
+)
+
+// Registration is the response to the register command application request
+type Registration struct {
+	ConnectionID int32
+	Success      bool
+	IsReadonly   bool
+	ErrorMessage string
+}
+
+// Lap is the lap time with the sector splits, the times are in milliseconds and -1 when not set
+type Lap struct {
+	LapTimeMs      int32
+	CarIndex       uint16
+	DriverIndex    uint16
+	Splits         []int32
+	IsInvalid      bool
+	IsValidForBest bool
+	IsOutLap       bool
+	IsInLap        bool
+}
+
+// Session is the state of the session sent in the realtime update
+type Session struct {
+	EventIndex          uint16
+	SessionIndex        uint16
+	SessionType         byte
+	Phase               byte
+	SessionTimeMs       float32
+	SessionEndTimeMs    float32
+	FocusedCarIndex     int32
+	ActiveCameraSet     string
+	ActiveCamera        string
+	CurrentHudPage      string
+	IsReplayPlaying     bool
+	ReplaySessionTime   float32
+	ReplayRemainingTime float32
+	TimeOfDayMs         float32
+	AmbientTemp         byte
+	TrackTemp           byte
+	Clouds              byte
+	RainLevel           byte
+	Wetness             byte
+	BestSessionLap      Lap
+}
+
+// CarUpdate is the position and timing of a single car sent in the realtime car update
+type CarUpdate struct {
+	CarIndex       uint16
+	DriverIndex    uint16
+	DriverCount    byte
+	Gear           int8
+	WorldPosX      float32
+	WorldPosY      float32
+	Yaw            float32
+	CarLocation    byte
+	Kmh            uint16
+	Position       uint16
+	CupPosition    uint16
+	TrackPosition  uint16
+	SplinePosition float32
+	Laps           uint16
+	DeltaMs        int32
+	BestSessionLap Lap
+	LastLap        Lap
+	CurrentLap     Lap
+}
+
+// Driver is a driver of the entry list car
+type Driver struct {
+	FirstName   string
+	LastName    string
+	ShortName   string
+	Category    byte
+	Nationality uint16
+}
+
+// Car is the entry list car
+type Car struct {
+	CarIndex           uint16
+	CarModelType       byte
+	TeamName           string
+	RaceNumber         int32
+	CupCategory        byte
+	CurrentDriverIndex byte
+	Nationality        uint16
+	Drivers            []Driver
+}
+
+// Track is the track sent in the track data
+type Track struct {
+	ConnectionID int32
+	Name         string
+	ID           int32
+	Meters       int32
+}
+
+// Event is the broadcasting event, eg. a penalty or the best lap
+type Event struct {
+	Type     byte
+	Message  string
+	TimeMs   int32
+	CarIndex int32
+}
+
+// reader reads the little endian message fields, the first read error is kept and every next read is ignored
+type reader struct {
+	buffer []byte
+	offset int
+	err    error
+}
+
+func newReader(buffer []byte) *reader {
+	return &reader{buffer: buffer, offset: 1} // the first byte is the message type
+}
+
+func (r *reader) next(size int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.offset+size > len(r.buffer) {
+		r.err = errors.Wrapf(ErrMessageTooShort, "type %d, %d bytes", r.buffer[0], len(r.buffer))
+		return nil
+	}
+	field := r.buffer[r.offset : r.offset+size]
+	r.offset += size
+	return field
+}
+
+func (r *reader) byte() byte {
+	if field := r.next(1); field != nil {
+		return field[0]
+	}
+	return 0
+}
+
+func (r *reader) bool() bool {
+	return r.byte() > 0
+}
+
+func (r *reader) uint16() uint16 {
+	if field := r.next(2); field != nil {
+		return binary.LittleEndian.Uint16(field)
+	}
+	return 0
+}
+
+func (r *reader) int32() int32 {
+	if field := r.next(4); field != nil {
+		return int32(binary.LittleEndian.Uint32(field))
+	}
+	return 0
+}
+
+func (r *reader) float32() float32 {
+	if field := r.next(4); field != nil {
+		return math.Float32frombits(binary.LittleEndian.Uint32(field))
+	}
+	return 0
+}
+
+// string reads the UTF-8 string prefixed with its length
+func (r *reader) string() string {
+	return string(r.next(int(r.uint16())))
+}
+
+func (r *reader) time() int32 {
+	value := r.int32()
+	if value == noTime {
+		return -1
+	}
+	return value
+}
+
+func (r *reader) lap() Lap {
+	lap := Lap{
+		LapTimeMs:   r.time(),
+		CarIndex:    r.uint16(),
+		DriverIndex: r.uint16(),
+	}
+	lap.Splits = make([]int32, r.byte())
+	for i := range lap.Splits {
+		lap.Splits[i] = r.time()
+	}
+	lap.IsInvalid = r.bool()
+	lap.IsValidForBest = r.bool()
+	lap.IsOutLap = r.bool()
+	lap.IsInLap = r.bool()
+	return lap
+}
+
+// ParseRegistration parses the registration result message
+func ParseRegistration(buffer []byte) (Registration, error) {
+	r := newReader(buffer)
+	registration := Registration{
+		ConnectionID: r.int32(),
+		Success:      r.bool(),
+		IsReadonly:   r.byte() == 0,
+		ErrorMessage: r.string(),
+	}
+	return registration, r.err
+}
+
+// ParseSession parses the realtime update message
+func ParseSession(buffer []byte) (Session, error) {
+	r := newReader(buffer)
+	session := Session{
+		EventIndex:       r.uint16(),
+		SessionIndex:     r.uint16(),
+		SessionType:      r.byte(),
+		Phase:            r.byte(),
+		SessionTimeMs:    r.float32(),
+		SessionEndTimeMs: r.float32(),
+		FocusedCarIndex:  r.int32(),
+		ActiveCameraSet:  r.string(),
+		ActiveCamera:     r.string(),
+		CurrentHudPage:   r.string(),
+		IsReplayPlaying:  r.bool(),
+	}
+	if session.IsReplayPlaying {
+		session.ReplaySessionTime = r.float32()
+		session.ReplayRemainingTime = r.float32()
+	}
+	session.TimeOfDayMs = r.float32()
+	session.AmbientTemp = r.byte()
+	session.TrackTemp = r.byte()
+	session.Clouds = r.byte()
+	session.RainLevel = r.byte()
+	session.Wetness = r.byte()
+	session.BestSessionLap = r.lap()
+	return session, r.err
+}
+
+// ParseCarUpdate parses the realtime car update message
+func ParseCarUpdate(buffer []byte) (CarUpdate, error) {
+	r := newReader(buffer)
+	update := CarUpdate{
+		CarIndex:    r.uint16(),
+		DriverIndex: r.uint16(),
+		DriverCount: r.byte(),
+		// the reverse gear is sent as 1 and the neutral as 2
+		Gear:           int8(r.byte()) - 2,
+		WorldPosX:      r.float32(),
+		WorldPosY:      r.float32(),
+		Yaw:            r.float32(),
+		CarLocation:    r.byte(),
+		Kmh:            r.uint16(),
+		Position:       r.uint16(),
+		CupPosition:    r.uint16(),
+		TrackPosition:  r.uint16(),
+		SplinePosition: r.float32(),
+		Laps:           r.uint16(),
+		DeltaMs:        r.int32(),
+	}
+	update.BestSessionLap = r.lap()
+	update.LastLap = r.lap()
+	update.CurrentLap = r.lap()
+	return update, r.err
+}
+
+// ParseEntryList parses the entry list message and returns the indexes of the cars
+func ParseEntryList(buffer []byte) ([]uint16, error) {
+	r := newReader(buffer)
+	r.int32() // connection id
+	indexes := make([]uint16, r.uint16())
+	for i := range indexes {
+		indexes[i] = r.uint16()
+	}
+	return indexes, r.err
+}
+
+// ParseEntryListCar parses the entry list car message
+func ParseEntryListCar(buffer []byte) (Car, error) {
+	r := newReader(buffer)
+	car := Car{
+		CarIndex:           r.uint16(),
+		CarModelType:       r.byte(),
+		TeamName:           r.string(),
+		RaceNumber:         r.int32(),
+		CupCategory:        r.byte(),
+		CurrentDriverIndex: r.byte(),
+		Nationality:        r.uint16(),
+	}
+	car.Drivers = make([]Driver, r.byte())
+	for i := range car.Drivers {
+		car.Drivers[i] = Driver{
+			FirstName:   r.string(),
+			LastName:    r.string(),
+			ShortName:   r.string(),
+			Category:    r.byte(),
+			Nationality: r.uint16(),
+		}
+	}
+	return car, r.err
+}
+
+// ParseTrack parses the track data message, the camera sets and HUD pages are skipped
+func ParseTrack(buffer []byte) (Track, error) {
+	r := newReader(buffer)
+	track := Track{
+		ConnectionID: r.int32(),
+		Name:         r.string(),
+		ID:           r.int32(),
+		Meters:       r.int32(),
+	}
+	return track, r.err
+}
+
+// ParseEvent parses the broadcasting event message
+func ParseEvent(buffer []byte) (Event, error) {
+	r := newReader(buffer)
+	event := Event{
+		Type:     r.byte(),
+		Message:  r.string(),
+		TimeMs:   r.int32(),
+		CarIndex: r.int32(),
+	}
+	return event, r.err
+}
+
+// RegisterRequest builds the register command application request
+func RegisterRequest(displayName, connectionPassword string, updateIntervalMs int32, commandPassword string) []byte {
+	request := []byte{RegisterCommandApplication, BroadcastingProtocolVersion}
+	request = appendString(request, displayName)
+	request = appendString(request, connectionPassword)
+	request = binary.LittleEndian.AppendUint32(request, uint32(updateIntervalMs))
+	return appendString(request, commandPassword)
+}
+
+// ConnectionRequest builds the request which contains only the connection id,
+// eg. the entry list, the track data or the unregister request
+func ConnectionRequest(messageType byte, connectionID int32) []byte {
+	return binary.LittleEndian.AppendUint32([]byte{messageType}, uint32(connectionID))
+}
+
+func appendString(buffer []byte, value string) []byte {
+	buffer = binary.LittleEndian.AppendUint16(buffer, uint16(len(value)))
+	return append(buffer, value...)
+}
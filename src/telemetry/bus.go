@@ -0,0 +1,162 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+)
+
+// DefaultQueueSize is the number of packets buffered for every adapter before new packets are dropped
+const DefaultQueueSize = 256
+
+// Bus broadcasts every published GameData to all registered adapters.
+// Each adapter reads from its own bounded queue, so a slow adapter only drops its own packets
+// and never blocks the other adapters or the packet decoder.
+type Bus struct {
+	subscribers []*subscriber
+	queueSize   int
+	cancel      context.CancelFunc
+	running     sync.WaitGroup
+	port        int
+}
+
+type subscriber struct {
+	adapter   ConverterInterface
+	queue     chan GameData
+	published atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewBus creates a new Bus with a queue of queueSize packets for every adapter
+func NewBus(adapters []ConverterInterface, queueSize int) *Bus {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	bus := &Bus{queueSize: queueSize}
+	for _, adapter := range adapters {
+		bus.subscribers = append(bus.subscribers, &subscriber{
+			adapter: adapter,
+			queue:   make(chan GameData, queueSize),
+		})
+	}
+	return bus
+}
+
+// Start runs every adapter in its own goroutine, reading from its own queue.
+// The context passed to the adapters is cancelled by Close, the counters are exported until then, see Metrics.
+func (b *Bus) Start(now time.Time, port int) {
+	var ctx context.Context
+	ctx, b.cancel = context.WithCancel(context.Background())
+	b.port = port
+	registerBus(port, b)
+	for _, sub := range b.subscribers {
+		b.running.Add(1)
+		go func(sub *subscriber) {
+			defer b.running.Done()
+			sub.adapter.ChannelInit(ctx, now, sub.queue, port)
+		}(sub)
+	}
+}
+
+// Publish sends the data to every adapter queue without blocking.
+// When an adapter queue is full the packet is dropped for that adapter only.
+// A nil Bus drops the data, eg. when the decoder is used before the adapters are started.
+func (b *Bus) Publish(data GameData) {
+	var subscribers []*subscriber
+	if b != nil {
+		subscribers = b.subscribers
+	}
+	// the publisher keeps a reference until the data is queued, so it is not released by the first adapter
+	data.retain(len(subscribers) + 1)
+	defer data.Release()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.queue <- data:
+			sub.published.Add(1)
+		default:
+			data.Release()
+			// logged once for every queue size of the dropped packets
+			if dropped := sub.dropped.Add(1); (dropped-1)%uint64(b.queueSize) == 0 {
+				log.Printf("[Bus] %T queue is full, %d packets dropped so far", sub.adapter, dropped)
+			}
+		}
+	}
+}
+
+// Dropped returns the number of packets dropped for every adapter, in the adapters order
+func (b *Bus) Dropped() []uint64 {
+	dropped := make([]uint64, len(b.subscribers))
+	for i, sub := range b.subscribers {
+		dropped[i] = sub.dropped.Load()
+	}
+	return dropped
+}
+
+// Close stops the adapters once they have converted the queued data and closes the adapters implementing io.Closer,
+// eg. to flush and close the files. It must be called after the last Publish, it logs the packets of every adapter.
+func (b *Bus) Close() error {
+	if b == nil || b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	b.running.Wait()
+	unregisterBus(b.port, b)
+
+	var errs []error
+	for _, sub := range b.subscribers {
+		if closer, ok := sub.adapter.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		log.Printf("[Bus] %T: %d packets queued, %d dropped", sub.adapter, sub.published.Load(), sub.dropped.Load())
+	}
+	return errors.Join(errs...)
+}
+
+// StartBus creates the bus of the handler adapters and starts it, the decoder publishes the data to Bus
+func (t *TelemetryHandler) StartBus(port int) *Bus {
+	t.Bus = NewBus(t.Adapters, DefaultQueueSize)
+	t.Bus.Start(time.Now(), port)
+	return t.Bus
+}
+
+// ProcessPackets runs the decoder on every packet of the channel, see Process. The bus is started before the first
+// packet and closed once the channel is closed, after the adapters have converted the queued data.
+func (t *TelemetryHandler) ProcessPackets(
+	channel chan server.Packet, port int, decoder func(packet server.Packet, port int) error,
+) {
+	defer t.StartBus(port).Close()
+
+	for packet := range channel {
+		t.Process(packet, port, decoder)
+	}
+}
+
+// Consume passes the data from the channel to convert until ctx is cancelled, then the data left in the channel.
+// The adapters read their queue with it in ChannelInit, so no published data is lost on shutdown.
+func Consume(ctx context.Context, channel chan GameData, convert func(data GameData)) {
+	for {
+		select {
+		case data := <-channel:
+			convert(data)
+		case <-ctx.Done():
+			for {
+				select {
+				case data := <-channel:
+					convert(data)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package telemetry_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_BroadcastsToEveryAdapter(t *testing.T) {
+	first, second := &test.RecordingAdapter{}, &test.RecordingAdapter{}
+	bus := telemetry.NewBus([]telemetry.ConverterInterface{first, second}, 10)
+	bus.Start(time.Now(), 1234)
+
+	for i := 0; i < 10; i++ {
+		bus.Publish(telemetry.GameData{Data: map[string]float32{"TimestampMS": float32(i)}})
+	}
+
+	assert.Eventually(t, func() bool {
+		return first.Count() == 10 && second.Count() == 10
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []uint64{0, 0}, bus.Dropped())
+}
+
+func TestBus_SlowAdapterDoesNotStallOthers(t *testing.T) {
+	slow := &test.RecordingAdapter{Block: make(chan struct{})}
+	fast := &test.RecordingAdapter{}
+	bus := telemetry.NewBus([]telemetry.ConverterInterface{slow, fast}, 2)
+	bus.Start(time.Now(), 1234)
+
+	for i := 0; i < 10; i++ {
+		bus.Publish(telemetry.GameData{})
+		assert.Eventually(t, func() bool { return fast.Count() == i+1 }, time.Second, time.Millisecond)
+	}
+	close(slow.Block)
+
+	dropped := bus.Dropped()
+	assert.Positive(t, dropped[0])
+	assert.Zero(t, dropped[1])
+	assert.Eventually(t, func() bool {
+		return slow.Count() == 10-int(dropped[0])
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBus_DropLogFollowsQueueSize(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	slow := &test.RecordingAdapter{Block: make(chan struct{})}
+	bus := telemetry.NewBus([]telemetry.ConverterInterface{slow}, 3)
+	bus.Start(time.Now(), 1234)
+	for i := 0; i < 20; i++ {
+		bus.Publish(telemetry.GameData{})
+	}
+	close(slow.Block)
+	assert.NoError(t, bus.Close())
+
+	// the full queue is logged once for every queue size of the dropped packets
+	dropped := int(bus.Dropped()[0])
+	assert.Equal(t, (dropped+2)/3, strings.Count(output.String(), "queue is full"))
+}
+
+type closingAdapter struct {
+	test.RecordingAdapter
+	closed bool
+}
+
+func (c *closingAdapter) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestBus_CloseWritesQueuedData(t *testing.T) {
+	adapter := &closingAdapter{RecordingAdapter: test.RecordingAdapter{Block: make(chan struct{})}}
+	bus := telemetry.NewBus([]telemetry.ConverterInterface{adapter}, 10)
+	bus.Start(time.Now(), 1234)
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(telemetry.GameData{})
+	}
+	closed := make(chan error)
+	go func() {
+		closed <- bus.Close()
+	}()
+	close(adapter.Block)
+
+	assert.NoError(t, <-closed)
+	assert.Equal(t, 5, adapter.Count())
+	assert.True(t, adapter.closed)
+}
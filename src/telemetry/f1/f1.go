@@ -0,0 +1,305 @@
+package f1
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"io/fs"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderSize is the size of the header sent at the beginning of every packet
+	HeaderSize = 29
+	// MaxCars is the number of cars sent in every car data packet
+	MaxCars = 22
+	// FormatsDirEnvKey is the environment variable with the directory of the F1 format files
+	FormatsDirEnvKey = "TMD_F1_FORMATS"
+)
+
+// Packet IDs of the supported packets
+const (
+	PacketMotion       uint8 = 0
+	PacketSession      uint8 = 1
+	PacketLapData      uint8 = 2
+	PacketCarTelemetry uint8 = 6
+	PacketCarStatus    uint8 = 7
+)
+
+// Format files of the supported packets. Car data formats describe the data of a single car.
+const (
+	SessionFormatFile      = "session"
+	MotionFormatFile       = "motion"
+	LapData2023FormatFile  = "lapdata2023"
+	LapData2024FormatFile  = "lapdata2024"
+	CarTelemetryFormatFile = "cartelemetry"
+	CarStatusFormatFile    = "carstatus"
+)
+
+var (
+	ErrPacketTooShort    = errors.WithMessage(telemetry.ErrPacketTooShort, "[F1]")
+	ErrUnsupportedFormat = errors.WithMessage(telemetry.ErrUnknownFormat, "[F1]")
+)
+
+//go:embed session motion lapdata2023 lapdata2024 cartelemetry carstatus
+var formatFiles embed.FS
+
+// lapDataFormats maps the packet format (game year) to the lap data format, the only format which differs
+var lapDataFormats = map[uint16]string{
+	2023: LapData2023FormatFile,
+	2024: LapData2024FormatFile,
+}
+
+// headerKeys are the channels taken from the packet header
+var headerKeys = []string{"IsRaceOn", "PacketFormat", "SessionTime", "FrameIdentifier", "PlayerCarIndex"}
+
+// Header is sent at the beginning of every packet
+type Header struct {
+	PacketFormat            uint16
+	GameYear                uint8
+	GameMajorVersion        uint8
+	GameMinorVersion        uint8
+	PacketVersion           uint8
+	PacketID                uint8
+	SessionUID              uint64
+	SessionTime             float32
+	FrameIdentifier         uint32
+	OverallFrameIdentifier  uint32
+	PlayerCarIndex          uint8
+	SecondaryPlayerCarIndex uint8
+}
+
+// ParseHeader reads the packet header
+func ParseHeader(buffer []byte) (Header, error) {
+	if len(buffer) < HeaderSize {
+		return Header{}, errors.Wrapf(ErrPacketTooShort, "header: %d bytes", len(buffer))
+	}
+
+	return Header{
+		PacketFormat:            binary.LittleEndian.Uint16(buffer[0:2]),
+		GameYear:                buffer[2],
+		GameMajorVersion:        buffer[3],
+		GameMinorVersion:        buffer[4],
+		PacketVersion:           buffer[5],
+		PacketID:                buffer[6],
+		SessionUID:              binary.LittleEndian.Uint64(buffer[7:15]),
+		SessionTime:             math.Float32frombits(binary.LittleEndian.Uint32(buffer[15:19])),
+		FrameIdentifier:         binary.LittleEndian.Uint32(buffer[19:23]),
+		OverallFrameIdentifier:  binary.LittleEndian.Uint32(buffer[23:27]),
+		PlayerCarIndex:          buffer[27],
+		SecondaryPlayerCarIndex: buffer[28],
+	}, nil
+}
+
+// F1Handler decodes the EA F1 23 and F1 24 UDP packets.
+// Every packet type carries a part of the data for all cars, the player car channels are merged
+// and published on every car telemetry packet.
+type F1Handler struct {
+	telemetry.TelemetryHandler
+	DebugMode string
+	// Keys contains every channel published to the adapters
+	Keys    []string
+	formats map[string]*telemetry.Schema
+	// players contains the merged player car values of every game sending to the port
+	players *telemetry.Sessions[map[string]telemetry.Value]
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// NewF1Handler creates a new F1Handler
+func NewF1Handler(debugMode string) *F1Handler {
+	return &F1Handler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.F1()),
+		},
+		DebugMode: debugMode,
+	}
+}
+
+// InitAndRun starts the F1Handler
+func (f1 *F1Handler) InitAndRun(ctx context.Context, port int) error {
+	err := f1.LoadFormats()
+	if err != nil {
+		return err
+	}
+
+	udpServer := server.NewServer("0.0.0.0:" + strconv.Itoa(port))
+
+	log.Printf("F1 UDP server listening on %s:%d, waiting for F1 data...\n", telemetry.GetOutboundIP(), port)
+
+	return udpServer.Run(ctx, f1.ProcessChannel, port)
+}
+
+// LoadFormats loads the packet formats and builds the list of published channels
+func (f1 *F1Handler) LoadFormats() error {
+	f1.formats = map[string]*telemetry.Schema{}
+	for _, name := range []string{
+		SessionFormatFile, MotionFormatFile, LapData2023FormatFile, LapData2024FormatFile,
+		CarTelemetryFormatFile, CarStatusFormatFile,
+	} {
+		schema, err := telemetry.LoadSchema(FormatsFS(), name)
+		if err != nil {
+			return err
+		}
+		f1.formats[name] = schema
+	}
+
+	keys := telemetry.MergeKeys(
+		headerKeys, f1.formats[SessionFormatFile].Keys, f1.formats[MotionFormatFile].Keys,
+		f1.formats[LapData2024FormatFile].Keys, f1.formats[LapData2023FormatFile].Keys,
+		f1.formats[CarTelemetryFormatFile].Keys, f1.formats[CarStatusFormatFile].Keys,
+	)
+	f1.Keys = keys
+	f1.players = telemetry.NewSessions(func() map[string]telemetry.Value {
+		return make(map[string]telemetry.Value, len(keys))
+	})
+
+	return nil
+}
+
+func (f1 *F1Handler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	f1.ProcessPackets(channel, port, f1.ProcessBuffer)
+}
+
+// ProcessBuffer dispatches the packet by its ID and merges the player car data.
+// The buffers of the merged packets are reused, the car telemetry packet buffer is released with the published data.
+func (f1 *F1Handler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	header, err := ParseHeader(buffer)
+	if err != nil {
+		return err
+	}
+
+	lapDataFormat, ok := lapDataFormats[header.PacketFormat]
+	if !ok {
+		return errors.Wrapf(ErrUnsupportedFormat, "%d", header.PacketFormat)
+	}
+	if header.PlayerCarIndex >= MaxCars {
+		// spectating, there is no player car
+		server.ReleaseBuffer(buffer)
+		return nil
+	}
+
+	player := f1.players.Get(packet.Source)
+	switch header.PacketID {
+	case PacketSession:
+		err = f1.mergeSession(player, buffer)
+	case PacketMotion:
+		err = f1.mergeCar(player, MotionFormatFile, buffer, header.PlayerCarIndex)
+	case PacketLapData:
+		err = f1.mergeCar(player, lapDataFormat, buffer, header.PlayerCarIndex)
+	case PacketCarStatus:
+		err = f1.mergeCar(player, CarStatusFormatFile, buffer, header.PlayerCarIndex)
+	case PacketCarTelemetry:
+		err = f1.mergeCar(player, CarTelemetryFormatFile, buffer, header.PlayerCarIndex)
+		if err != nil {
+			return err
+		}
+		f1.publish(player, header, packet)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	server.ReleaseBuffer(buffer)
+	return nil
+}
+
+// mergeSession stores the session data, which is the same for every car
+func (f1 *F1Handler) mergeSession(player map[string]telemetry.Value, buffer []byte) error {
+	schema := f1.formats[SessionFormatFile]
+	if len(buffer) < HeaderSize+schema.Size {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d bytes", schema.Name, len(buffer))
+	}
+
+	schema.MergeValues(buffer[HeaderSize:], player)
+	return nil
+}
+
+// mergeCar stores the data of the player car from the packet with the data of all cars
+func (f1 *F1Handler) mergeCar(player map[string]telemetry.Value, format string, buffer []byte, carIndex uint8) error {
+	schema := f1.formats[format]
+	if len(buffer) < HeaderSize+MaxCars*schema.Size {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d bytes", schema.Name, len(buffer))
+	}
+
+	start := HeaderSize + int(carIndex)*schema.Size
+	schema.MergeValues(buffer[start:start+schema.Size], player)
+	return nil
+}
+
+// publish sends a copy of the merged player car data to the adapters
+func (f1 *F1Handler) publish(player map[string]telemetry.Value, header Header, packet server.Packet) {
+	decoded := f1.samples.Get()
+	decoded.Buffer = packet.Data
+	values := decoded.Values
+	for key, value := range player {
+		values[key] = value
+	}
+	values["PacketFormat"] = telemetry.Value{DataType: "U16", Int: int64(header.PacketFormat)}
+	values["SessionTime"] = telemetry.Value{DataType: "F32", Float: float64(header.SessionTime)}
+	values["FrameIdentifier"] = telemetry.Value{DataType: "U32", Int: int64(header.FrameIdentifier)}
+	values["PlayerCarIndex"] = telemetry.Value{DataType: "U8", Int: int64(header.PlayerCarIndex)}
+
+	data := decoded.Data
+	telemetry.FloatsInto(values, data)
+	data["IsRaceOn"] = 1
+	if data["GamePaused"] != 0 {
+		data["IsRaceOn"] = 0
+	}
+	decoded.Normalized = normalize(data)
+
+	published := decoded.GameData(f1.Keys, packet.Source)
+	published.Normalized = &decoded.Normalized
+	f1.Bus.Publish(published)
+}
+
+// normalize maps the merged player car values to the normalized sample
+func normalize(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn: values["IsRaceOn"] != 0,
+		IsPlayer: true,
+		Speed:    values["Speed"] / 3.6,
+		RPM:      values["EngineRPM"],
+		MaxRPM:   values["MaxRPM"],
+		IdleRPM:  values["IdleRPM"],
+		Gear:     int(values["Gear"]),
+		Throttle: values["Throttle"],
+		Brake:    values["Brake"],
+		Clutch:   values["Clutch"] / 100,
+		Steer:    values["Steer"],
+		Fuel:     telemetry.Ratio(values["FuelInTank"], values["FuelCapacity"]),
+		Position: telemetry.VectorOf(values, "WorldPosition"),
+		Velocity: telemetry.VectorOf(values, "WorldVelocity"),
+		Acceleration: telemetry.Vector3{
+			X: values["GForceLateral"] * telemetry.StandardGravity,
+			Y: values["GForceVertical"] * telemetry.StandardGravity,
+			Z: values["GForceLongitudinal"] * telemetry.StandardGravity,
+		},
+		Yaw:                values["Yaw"],
+		Pitch:              values["Pitch"],
+		Roll:               values["Roll"],
+		TireTemperature:    telemetry.WheelsOf(values, "TyresSurfaceTemperature"),
+		HasTireTemperature: true,
+		LapNumber:          max(int(values["CurrentLapNum"])-1, 0),
+		RacePosition:       int(values["CarPosition"]),
+		CurrentLap:         values["CurrentLapTimeInMS"] / 1000,
+		LastLap:            values["LastLapTimeInMS"] / 1000,
+		Distance:           values["TotalDistance"],
+		TrackID:            int(values["TrackId"]),
+	}
+}
+
+// FormatsFS returns the F1 format files, TMD_F1_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
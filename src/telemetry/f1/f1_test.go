@@ -0,0 +1,147 @@
+package f1_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/f1"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const playerCarIndex = 3
+
+func TestParseHeader(t *testing.T) {
+	header, err := f1.ParseHeader(packet(2024, f1.PacketCarTelemetry, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(2024), header.PacketFormat)
+	assert.Equal(t, f1.PacketCarTelemetry, header.PacketID)
+	assert.Equal(t, uint64(0x1122334455667788), header.SessionUID)
+	assert.Equal(t, float32(12.5), header.SessionTime)
+	assert.Equal(t, uint32(4321), header.FrameIdentifier)
+	assert.Equal(t, uint8(playerCarIndex), header.PlayerCarIndex)
+
+	_, err = f1.ParseHeader(make([]byte, 10))
+	assert.ErrorIs(t, err, f1.ErrPacketTooShort)
+}
+
+func TestF1Handler_MergesPlayerCarData(t *testing.T) {
+	for _, tc := range []struct {
+		packetFormat uint16
+		lapDataSize  int
+	}{
+		{packetFormat: 2023, lapDataSize: 50},
+		{packetFormat: 2024, lapDataSize: 57},
+	} {
+		adapter := &test.RecordingAdapter{}
+		handler := &f1.F1Handler{
+			TelemetryHandler: telemetry.TelemetryHandler{
+				Adapters: []telemetry.ConverterInterface{adapter},
+			},
+		}
+		require.NoError(t, handler.LoadFormats())
+		channel := make(chan server.Packet)
+		go handler.ProcessChannel(context.Background(), channel, 20777)
+
+		session := packet(tc.packetFormat, f1.PacketSession, 700)
+		session[f1.HeaderSize+3] = 57                                          // TotalLaps
+		session[f1.HeaderSize+7] = 10                                          // TrackId
+		binary.LittleEndian.PutUint16(session[f1.HeaderSize+4:], uint16(7004)) // TrackLength
+		channel <- server.Packet{Data: session}
+
+		motion := packet(tc.packetFormat, f1.PacketMotion, f1.MaxCars*60)
+		putFloat(motion, carOffset(60, playerCarIndex), 123.5) // WorldPositionX
+		putFloat(motion, carOffset(60, 0), 999)                // WorldPositionX of another car
+		channel <- server.Packet{Data: motion}
+
+		lapData := packet(tc.packetFormat, f1.PacketLapData, f1.MaxCars*tc.lapDataSize+2)
+		binary.LittleEndian.PutUint32(lapData[carOffset(tc.lapDataSize, playerCarIndex):], 91234) // LastLapTimeInMS
+		channel <- server.Packet{Data: lapData}
+
+		carTelemetry := packet(tc.packetFormat, f1.PacketCarTelemetry, f1.MaxCars*60+3)
+		binary.LittleEndian.PutUint16(carTelemetry[carOffset(60, playerCarIndex):], 287) // Speed
+		carTelemetry[carOffset(60, playerCarIndex)+15] = 7                               // Gear
+		channel <- server.Packet{Data: carTelemetry}
+
+		assert.Eventually(t, func() bool { return adapter.Count() == 1 }, time.Second, 10*time.Millisecond)
+		data := adapter.All()[0]
+
+		assert.Equal(t, float32(1), data.Data["IsRaceOn"])
+		assert.Equal(t, float32(tc.packetFormat), data.Data["PacketFormat"])
+		assert.Equal(t, float32(57), data.Data["TotalLaps"])
+		assert.Equal(t, float32(10), data.Data["TrackId"])
+		assert.Equal(t, float32(7004), data.Data["TrackLength"])
+		assert.Equal(t, float32(123.5), data.Data["WorldPositionX"])
+		assert.Equal(t, float32(91234), data.Data["LastLapTimeInMS"])
+		assert.Equal(t, float32(287), data.Data["Speed"])
+		assert.Equal(t, float32(7), data.Data["Gear"])
+		assert.Equal(t, telemetry.Value{DataType: "U32", Int: 91234}, data.Values["LastLapTimeInMS"])
+		assert.Equal(t, telemetry.Value{DataType: "U16", Int: int64(tc.packetFormat)}, data.Values["PacketFormat"])
+		assert.NotContains(t, data.Values, "IsRaceOn", "the calculated channels are only in Data")
+
+		require.NotNil(t, data.Normalized)
+		assert.InDelta(t, 287/3.6, data.Normalized.Speed, 0.001)
+		assert.Equal(t, 7, data.Normalized.Gear)
+		assert.Equal(t, float32(123.5), data.Normalized.Position.X)
+		assert.InDelta(t, 91.234, data.Normalized.LastLap, 0.001)
+		assert.Equal(t, 10, data.Normalized.TrackID)
+		assert.Equal(t, handler.Keys, data.Keys)
+		assert.Equal(t, carTelemetry, data.RawData)
+	}
+}
+
+func TestF1Handler_RejectsInvalidPackets(t *testing.T) {
+	handler := &f1.F1Handler{}
+	require.NoError(t, handler.LoadFormats())
+
+	err := handler.ProcessBuffer(server.Packet{Data: packet(2022, f1.PacketMotion, f1.MaxCars*60)}, 20777)
+	assert.ErrorIs(t, err, f1.ErrUnsupportedFormat)
+
+	err = handler.ProcessBuffer(server.Packet{Data: packet(2024, f1.PacketMotion, 60)}, 20777)
+	assert.ErrorIs(t, err, f1.ErrPacketTooShort)
+
+	err = handler.ProcessBuffer(server.Packet{Data: packet(2024, f1.PacketSession, 4)}, 20777)
+	assert.ErrorIs(t, err, f1.ErrPacketTooShort)
+}
+
+func TestF1Handler_KeysAreUnique(t *testing.T) {
+	handler := &f1.F1Handler{}
+	require.NoError(t, handler.LoadFormats())
+
+	seen := map[string]bool{}
+	for _, key := range handler.Keys {
+		assert.False(t, seen[key], key)
+		seen[key] = true
+	}
+	assert.Contains(t, handler.Keys, "SpeedTrapFastestSpeed")
+	assert.Contains(t, handler.Keys, "ERSStoreEnergy")
+}
+
+// packet builds a packet with the header and empty payload
+func packet(packetFormat uint16, packetID uint8, payloadSize int) []byte {
+	buffer := make([]byte, f1.HeaderSize+payloadSize)
+	binary.LittleEndian.PutUint16(buffer[0:], packetFormat)
+	buffer[2] = byte(packetFormat % 100)
+	buffer[6] = packetID
+	binary.LittleEndian.PutUint64(buffer[7:], 0x1122334455667788)
+	putFloat(buffer, 15, 12.5)
+	binary.LittleEndian.PutUint32(buffer[19:], 4321)
+	buffer[27] = playerCarIndex
+	buffer[28] = 255
+	return buffer
+}
+
+func carOffset(carDataSize, carIndex int) int {
+	return f1.HeaderSize + carDataSize*carIndex
+}
+
+func putFloat(buffer []byte, offset int, value float32) {
+	binary.LittleEndian.PutUint32(buffer[offset:], math.Float32bits(value))
+}
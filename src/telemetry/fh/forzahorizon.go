@@ -0,0 +1,30 @@
+package fh
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023"
+)
+
+// DataFormatFile describes the 324 bytes Forza Horizon 4 and Forza Horizon 5 "Car Dash" packet
+const DataFormatFile = "forzahorizon"
+
+// FormatsDirEnvKey is the environment variable with the directory of the Forza Horizon format files
+const FormatsDirEnvKey = "TMD_FORZAH_FORMATS"
+
+//go:embed forzahorizon
+var formatFiles embed.FS
+
+// NewForzaHorizonHandler creates a new handler for Forza Horizon 4 and Forza Horizon 5.
+// Forza Horizon uses the Forza Motorsport Dash layout with extra bytes, so the Forza Motorsport handler is reused.
+func NewForzaHorizonHandler(debugMode string) *fms2023.ForzaMotorsportHandler {
+	return fms2023.NewForzaHandler(enums.Games.ForzaHorizon(), FormatsFS(), []string{DataFormatFile}, debugMode)
+}
+
+// FormatsFS returns the Forza Horizon format files, TMD_FORZAH_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
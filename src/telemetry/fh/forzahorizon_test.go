@@ -0,0 +1,53 @@
+package fh_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fh"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023"
+	_ "github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForzaHorizonFormat(t *testing.T) {
+	fm := fh.NewForzaHorizonHandler("")
+	require.NoError(t, fm.LoadFormats())
+	require.Len(t, fm.Formats, 1)
+
+	schema := fm.Formats[324]
+	require.NotNil(t, schema)
+	assert.Len(t, schema.Keys, 85)
+	assert.Equal(t, 228, schema.Telemetries["NumCylinders"].StartOffset)
+	assert.Equal(t, 244, schema.Telemetries["PositionX"].StartOffset)
+	assert.Equal(t, 322, schema.Telemetries["NormalizedAIBrakeDifference"].StartOffset)
+	assert.NotContains(t, schema.Telemetries, "TrackOrdinal")
+}
+
+func TestForzaHorizonDecode(t *testing.T) {
+	lines, err := telemetry.ReadLines("fms2023/forzamotorsport.udp.log")
+	require.NoError(t, err)
+	forzaMotorsport, err := base64.StdEncoding.DecodeString(lines[0])
+	require.NoError(t, err)
+
+	// build a Forza Horizon packet from the recorded Forza Motorsport Dash packet
+	forzaHorizon := append([]byte{}, forzaMotorsport[:232]...)
+	forzaHorizon = append(forzaHorizon, make([]byte, 12)...)
+	forzaHorizon = append(forzaHorizon, forzaMotorsport[232:311]...)
+	forzaHorizon = append(forzaHorizon, 0)
+
+	fm := fh.NewForzaHorizonHandler("")
+	require.NoError(t, fm.LoadFormats())
+	fms := &fms2023.ForzaMotorsportHandler{}
+	require.NoError(t, fms.LoadFormats())
+
+	horizonValues := fm.Formats[len(forzaHorizon)].Decode(forzaHorizon)
+	motorsportValues := fms.Formats[331].Decode(forzaMotorsport[:331])
+
+	for _, key := range []string{"CarOrdinal", "PositionX", "Speed", "CurrentEngineRpm", "LapNumber", "Gear", "Steer"} {
+		assert.Equal(t, motorsportValues[key], horizonValues[key], key)
+	}
+	assert.NotZero(t, horizonValues["Speed"])
+}
@@ -0,0 +1,27 @@
+package formats
+
+import (
+	"embed"
+	"io/fs"
+)
+
+const (
+	// DataFormatFile describes the 331 bytes Forza Motorsport 2023 packet
+	DataFormatFile = "forzamotorsport"
+	// DashFormatFile describes the 311 bytes Forza Motorsport 7 "Car Dash" packet
+	DashFormatFile = "forzamotorsport7dash"
+	// SledFormatFile describes the 232 bytes Forza Motorsport 7 "Sled" packet
+	SledFormatFile = "forzamotorsport7sled"
+)
+
+// NeutralGear is the gear sent in the neutral, the reverse is sent as 0
+const NeutralGear = 11
+
+//go:embed forzamotorsport forzamotorsport7dash forzamotorsport7sled
+var formatFiles embed.FS
+
+// FS returns the file system with the built-in Forza Motorsport packet formats.
+// The formats are in their own package so the forza adapter encodes the same packet the handler decodes.
+func FS() fs.FS {
+	return formatFiles
+}
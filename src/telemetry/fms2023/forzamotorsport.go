@@ -1,98 +1,197 @@
 package fms2023
 
 import (
-	"encoding/binary"
+	"context"
+	"io/fs"
 	"log"
-	"math"
 	"strconv"
-	"time"
 
 	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
 	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
 	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
 	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023/formats"
+	"github.com/pkg/errors"
 )
 
-const DataFormatFile = "forzamotorsport"
+const (
+	// DataFormatFile describes the 331 bytes Forza Motorsport 2023 packet
+	DataFormatFile = formats.DataFormatFile
+	// DashFormatFile describes the 311 bytes Forza Motorsport 7 "Car Dash" packet
+	DashFormatFile = formats.DashFormatFile
+	// SledFormatFile describes the 232 bytes Forza Motorsport 7 "Sled" packet
+	SledFormatFile = formats.SledFormatFile
+)
+
+// FormatsDirEnvKey is the environment variable with the directory of the Forza Motorsport format files
+const FormatsDirEnvKey = "TMD_FORZAM_FORMATS"
+
+// NeutralGear is the gear sent in the neutral
+const NeutralGear = formats.NeutralGear
+
+var ErrUnknownPacket = errors.WithMessage(telemetry.ErrUnknownFormat, "[Forza]")
 
+// ForzaMotorsportHandler decodes the Forza "Data Out" packets.
+// The same handler is used by every Forza game, which differ only by the packet formats.
 type ForzaMotorsportHandler struct {
 	telemetry.TelemetryHandler
-	DebugMode string
+	Game        enums.Game
+	FormatFS    fs.FS
+	FormatFiles []string
+	DebugMode   string
+	// samples contains the decoded samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
 }
 
-var gameTelemetryData = make(chan telemetry.GameData)
-
 // NewForzaMotorsportHandler creates a new ForzaMotorsportHandler
 func NewForzaMotorsportHandler(debugMode string) *ForzaMotorsportHandler {
+	return NewForzaHandler(
+		enums.Games.ForzaMotorsport2023(),
+		FormatsFS(),
+		[]string{DataFormatFile, DashFormatFile, SledFormatFile},
+		debugMode,
+	)
+}
+
+// NewForzaHandler creates a new handler for any Forza game with the given packet formats
+func NewForzaHandler(game enums.Game, formatFS fs.FS, formatFiles []string, debugMode string) *ForzaMotorsportHandler {
 	return &ForzaMotorsportHandler{
 		TelemetryHandler: telemetry.TelemetryHandler{
-			Adapters: converter.SetupAdapter(enums.Games.ForzaMotorsport2023()),
+			Adapters: converter.SetupAdapter(game),
 		},
-		DebugMode: debugMode,
+		Game:        game,
+		FormatFS:    formatFS,
+		FormatFiles: formatFiles,
+		DebugMode:   debugMode,
 	}
 }
 
 // InitAndRun starts the ForzaMotorsportHandler
-func (fm *ForzaMotorsportHandler) InitAndRun(port int) error {
+func (fm *ForzaMotorsportHandler) InitAndRun(ctx context.Context, port int) error {
+	err := fm.LoadFormats()
+	if err != nil {
+		return err
+	}
+
 	udpServer := server.NewServer("0.0.0.0:" + strconv.Itoa(port))
-	fm.TelemetryHandler.Telemetries, fm.TelemetryHandler.Keys = telemetry.Telemetries()
 
-	log.Printf("Forza data out server listening on %s:%d, waiting for Forza data...\n", telemetry.GetOutboundIP(), port)
+	log.Printf(
+		"[%s] Forza data out server listening on %s:%d, waiting for Forza data...\n",
+		fm.Game, telemetry.GetOutboundIP(), port,
+	)
 
-	err := udpServer.Run(fm.ProcessChannel, port)
-	defer udpServer.Close()
+	return udpServer.Run(ctx, fm.ProcessChannel, port)
+}
+
+func (fm *ForzaMotorsportHandler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	fm.ProcessPackets(channel, port, fm.ProcessBuffer)
+}
+
+// LoadFormats loads the handler packet formats.
+// Without configured formats the Forza Motorsport 2023 and Forza Motorsport 7 Dash and Sled formats are loaded.
+func (fm *ForzaMotorsportHandler) LoadFormats() error {
+	formatFS, formatFiles := fm.FormatFS, fm.FormatFiles
+	if formatFS == nil {
+		formatFS, formatFiles = FormatsFS(), []string{DataFormatFile, DashFormatFile, SledFormatFile}
+	}
+
+	loaded, err := telemetry.LoadFormats(formatFS, formatFiles...)
 	if err != nil {
 		return err
 	}
+	fm.TelemetryHandler.Formats = loaded
 	return nil
 }
 
-func (fm *ForzaMotorsportHandler) ProcessChannel(channel chan []byte, port int) {
-	for _, adapter := range fm.TelemetryHandler.Adapters {
-		go adapter.ChannelInit(time.Now(), gameTelemetryData, port)
+// ProcessBuffer processes the received data.
+// The packet format is selected by the packet length, fields missing in the format are not set.
+// The decoded sample and the buffer are reused once every adapter has released the published data.
+func (fm *ForzaMotorsportHandler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	schema, ok := fm.TelemetryHandler.Formats[len(buffer)]
+	if !ok {
+		return errors.Wrapf(ErrUnknownPacket, "%d bytes", len(buffer))
 	}
 
-	//nolint:gosimple // loop is needed to keep the channel open
-	for {
-		select {
-		case data := <-channel:
-			fm.ProcessBuffer(data, port)
-		}
+	decoded := fm.samples.Get()
+	decoded.Buffer = buffer
+	schema.DecodeValuesInto(buffer, decoded.Values)
+	telemetry.FloatsInto(decoded.Values, decoded.Data)
+
+	if decoded.Data["IsRaceOn"] == 0 {
+		fm.samples.Put(decoded)
+		return nil
 	}
+	decoded.Normalized = normalize(decoded.Data)
+
+	data := decoded.GameData(schema.Keys, packet.Source)
+	data.Normalized = &decoded.Normalized
+	fm.Bus.Publish(data)
+	return nil
 }
 
-// ProcessBuffer processes the received data
-func (fm *ForzaMotorsportHandler) ProcessBuffer(buffer []byte, port int) {
-	tempTelemetry := make(map[string]float32, len(fm.TelemetryHandler.Telemetries))
-
-	for i, telemetryObj := range fm.TelemetryHandler.Telemetries {
-		data := buffer[telemetryObj.StartOffset:telemetryObj.EndOffset]
-
-		var value float32
-		switch telemetryObj.DataType {
-		case "F32":
-			value = math.Float32frombits(binary.LittleEndian.Uint32(data))
-		case "U8":
-			value = float32(data[0])
-		case "S8":
-			value = float32(int8(data[0]))
-		case "U16":
-			value = float32(binary.LittleEndian.Uint16(data))
-		default:
-			value = float32(binary.LittleEndian.Uint32(data))
-		}
+// Normalize maps the Forza Dash values to the normalized sample.
+// Forza sends the reverse as the gear 0 and the neutral as the gear 11.
+func Normalize(values map[string]float32) *telemetry.Normalized {
+	normalized := normalize(values)
+	return &normalized
+}
 
-		tempTelemetry[i] = value
+func normalize(values map[string]float32) telemetry.Normalized {
+	gear := int(values["Gear"])
+	switch gear {
+	case 0:
+		gear = -1
+	case NeutralGear:
+		gear = 0
 	}
 
-	if tempTelemetry["IsRaceOn"] == 0 {
-		return
+	return telemetry.Normalized{
+		IsRaceOn:           values["IsRaceOn"] != 0,
+		IsPlayer:           true,
+		Speed:              values["Speed"],
+		RPM:                values["CurrentEngineRpm"],
+		MaxRPM:             values["EngineMaxRpm"],
+		IdleRPM:            values["EngineIdleRpm"],
+		Gear:               gear,
+		Throttle:           values["Accel"] / 255,
+		Brake:              values["Brake"] / 255,
+		Clutch:             values["Clutch"] / 255,
+		HandBrake:          values["HandBrake"] / 255,
+		Steer:              values["Steer"] / 127,
+		Fuel:               values["Fuel"],
+		Position:           telemetry.VectorOf(values, "Position"),
+		Velocity:           telemetry.VectorOf(values, "Velocity"),
+		Acceleration:       telemetry.VectorOf(values, "Acceleration"),
+		Yaw:                values["Yaw"],
+		Pitch:              values["Pitch"],
+		Roll:               values["Roll"],
+		WheelSpeed:         telemetry.WheelsOf(values, "WheelRotationSpeed"),
+		TireTemperature:    fahrenheitToCelsius(telemetry.WheelsOf(values, "TireTemp")),
+		HasTireTemperature: true,
+		SuspensionTravel:   telemetry.WheelsOf(values, "SuspensionTravelMeters"),
+		LapNumber:          int(values["LapNumber"]),
+		RacePosition:       int(values["RacePosition"]),
+		CurrentLap:         values["CurrentLap"],
+		LastLap:            values["LastLap"],
+		BestLap:            values["BestLap"],
+		Distance:           values["DistanceTraveled"],
+		CarID:              int(values["CarOrdinal"]),
+		CarClass:           int(values["CarClass"]),
+		TrackID:            int(values["TrackOrdinal"]),
 	}
+}
 
-	data := telemetry.GameData{
-		Keys:    fm.TelemetryHandler.Keys,
-		Data:    tempTelemetry,
-		RawData: buffer,
+func fahrenheitToCelsius(temperatures telemetry.Wheels) telemetry.Wheels {
+	for i, temperature := range temperatures {
+		if temperature != 0 {
+			temperatures[i] = (temperature - 32) * 5 / 9
+		}
 	}
-	gameTelemetryData <- data
+	return temperatures
+}
+
+// FormatsFS returns the Forza Motorsport format files, TMD_FORZAM_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formats.FS(), FormatsDirEnvKey)
 }
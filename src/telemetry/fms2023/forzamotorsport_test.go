@@ -1,26 +1,263 @@
 package fms2023_test
 
 import (
+	"context"
+	"encoding/base64"
 	"log"
 	"testing"
+	"time"
 
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
 	"github.com/bluemanos/simracing-telemetry/src/telemetry"
 	"github.com/bluemanos/simracing-telemetry/src/telemetry/fms2023"
-	_ "github.com/bluemanos/simracing-telemetry/test"
+	"github.com/bluemanos/simracing-telemetry/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestInitTelemetries(t *testing.T) {
-	fm := &fms2023.ForzaMotorsportHandler{
-		TelemetryHandler: telemetry.TelemetryHandler{},
-	}
-	fm.Telemetries, fm.Keys = telemetry.Telemetries()
+	schema, err := telemetry.LoadSchema(fms2023.FormatsFS(), fms2023.DataFormatFile)
+	require.NoError(t, err)
 
-	lines, err := telemetry.ReadLines("fms2023/" + fms2023.DataFormatFile)
+	lines, err := telemetry.ReadLines("fms2023/formats/" + fms2023.DataFormatFile)
 	if err != nil {
 		log.Fatalf("Error reading format file: %s", err)
 	}
 
-	assert.Equal(t, len(fm.Telemetries), len(fm.Keys))
-	assert.Equal(t, len(lines), len(fm.Telemetries))
+	assert.Equal(t, len(schema.Telemetries), len(schema.Keys))
+	assert.Equal(t, len(lines), len(schema.Telemetries))
+	assert.Equal(t, 331, schema.Size)
+	assert.Equal(t, telemetry.TelemetryData{
+		Position: 89, Name: "TrackOrdinal", DataType: "S32", StartOffset: 327, EndOffset: 331,
+	}, schema.Telemetries["TrackOrdinal"])
+	assert.Equal(t, telemetry.TelemetryData{
+		Position: 75, Name: "LapNumber", DataType: "U16", StartOffset: 300, EndOffset: 302,
+	}, schema.Telemetries["LapNumber"])
+}
+
+func TestFormatsDirectoryOverride(t *testing.T) {
+	t.Setenv(fms2023.FormatsDirEnvKey, "src/telemetry/fms2023/formats")
+
+	schema, err := telemetry.LoadSchema(fms2023.FormatsFS(), fms2023.DataFormatFile)
+	require.NoError(t, err)
+	assert.Equal(t, 331, schema.Size)
+
+	t.Setenv(fms2023.FormatsDirEnvKey, "src/telemetry")
+	_, err = telemetry.LoadSchema(fms2023.FormatsFS(), fms2023.DataFormatFile)
+	assert.Error(t, err)
+}
+
+func TestLoadFormats(t *testing.T) {
+	fm := &fms2023.ForzaMotorsportHandler{}
+	require.NoError(t, fm.LoadFormats())
+
+	assert.Len(t, fm.Formats, 3)
+	assert.Equal(t, fms2023.DataFormatFile, fm.Formats[331].Name)
+	assert.Equal(t, fms2023.DashFormatFile, fm.Formats[311].Name)
+	assert.Equal(t, fms2023.SledFormatFile, fm.Formats[232].Name)
+	assert.Len(t, fm.Formats[311].Keys, 85)
+	assert.Len(t, fm.Formats[232].Keys, 58)
 }
+
+func TestProcessBuffer_SelectsFormatByLength(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	fm := &fms2023.ForzaMotorsportHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: []telemetry.ConverterInterface{adapter},
+		},
+	}
+	require.NoError(t, fm.LoadFormats())
+
+	channel := make(chan server.Packet)
+	go fm.ProcessChannel(context.Background(), channel, 1234)
+
+	packet := recordedPackets(t)[0]
+	channel <- server.Packet{Data: packet[:331]}
+	channel <- server.Packet{Data: packet[:311]}
+	channel <- server.Packet{Data: packet[:232]}
+	channel <- server.Packet{Data: packet[:100]}
+
+	assert.Eventually(t, func() bool { return adapter.Count() == 3 }, time.Second, 10*time.Millisecond)
+	received := adapter.All()
+	assert.Equal(t, map[string]uint64{telemetry.RejectUnknownFormat: 1}, fm.Rejects.Counts())
+
+	assert.Len(t, received[0].Keys, 90)
+	assert.Len(t, received[1].Keys, 85)
+	assert.Len(t, received[2].Keys, 58)
+
+	for _, data := range received {
+		assert.Equal(t, received[0].Data["CarOrdinal"], data.Data["CarOrdinal"])
+	}
+	assert.Equal(t, received[0].Data["Speed"], received[1].Data["Speed"])
+	assert.NotContains(t, received[1].Data, "TrackOrdinal")
+	assert.NotContains(t, received[2].Data, "Speed")
+
+	normalized := received[0].Normalized
+	require.NotNil(t, normalized)
+	assert.Equal(t, received[0].Data["Speed"], normalized.Speed)
+	assert.Equal(t, int(received[0].Data["CarOrdinal"]), normalized.CarID)
+	assert.Equal(t, received[0].Data["Accel"]/255, normalized.Throttle)
+}
+
+func TestForzaMotorsportHandler_IsolatedInstances(t *testing.T) {
+	t.Parallel()
+
+	schema, err := telemetry.LoadSchema(fms2023.FormatsFS(), fms2023.DataFormatFile)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 2)
+	adapters := map[float32]*test.RecordingAdapter{}
+	for _, carOrdinal := range []float32{1, 2} {
+		adapter := &test.RecordingAdapter{}
+		adapters[carOrdinal] = adapter
+		fm := &fms2023.ForzaMotorsportHandler{
+			TelemetryHandler: telemetry.TelemetryHandler{
+				Adapters: []telemetry.ConverterInterface{adapter},
+			},
+		}
+		port := test.FreePort(t)
+		go func() {
+			stopped <- fm.InitAndRun(ctx, port)
+		}()
+
+		// every handler receives only the packets sent to its own port
+		packet := schema.Encode(map[string]float32{"IsRaceOn": 1, "CarOrdinal": carOrdinal})
+		connection := test.Dial(t, port)
+		require.Eventually(t, func() bool {
+			_, _ = connection.Write(packet)
+			return adapter.Count() > 0
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	cancel()
+	require.NoError(t, <-stopped)
+	require.NoError(t, <-stopped)
+	for carOrdinal, adapter := range adapters {
+		for _, data := range adapter.All() {
+			assert.Equal(t, carOrdinal, data.Data["CarOrdinal"])
+		}
+	}
+}
+
+func TestNormalize_Gear(t *testing.T) {
+	assert.Equal(t, -1, fms2023.Normalize(map[string]float32{"Gear": 0}).Gear)
+	assert.Equal(t, 0, fms2023.Normalize(map[string]float32{"Gear": fms2023.NeutralGear}).Gear)
+	assert.Equal(t, 4, fms2023.Normalize(map[string]float32{"Gear": 4}).Gear)
+}
+
+func FuzzForzaMotorsportHandler_ProcessBuffer(f *testing.F) {
+	for _, packet := range recordedPackets(f) {
+		f.Add(packet)
+		f.Add(packet[:311])
+		f.Add(packet[:232])
+		f.Add(packet[:100])
+	}
+	f.Add([]byte{})
+
+	fm := &fms2023.ForzaMotorsportHandler{}
+	require.NoError(f, fm.LoadFormats())
+
+	f.Fuzz(func(t *testing.T, buffer []byte) {
+		err := fm.ProcessBuffer(server.Packet{Data: buffer}, 1234)
+		if _, ok := fm.Formats[len(buffer)]; ok {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, telemetry.ErrUnknownFormat)
+		}
+	})
+}
+
+func FuzzSchema_DecodeValues(f *testing.F) {
+	for _, packet := range recordedPackets(f) {
+		f.Add(packet)
+		f.Add(packet[:len(packet)/2])
+	}
+
+	schema, err := telemetry.LoadSchema(fms2023.FormatsFS(), fms2023.DataFormatFile)
+	require.NoError(f, err)
+
+	f.Fuzz(func(t *testing.T, buffer []byte) {
+		values := schema.DecodeValues(buffer)
+		if err := schema.Check(buffer); err != nil {
+			assert.ErrorIs(t, err, telemetry.ErrPacketTooShort)
+			assert.Less(t, len(values), len(schema.Keys))
+			return
+		}
+		assert.Len(t, values, len(schema.Keys))
+	})
+}
+
+func BenchmarkForzaMotorsportHandler_ProcessBuffer(b *testing.B) {
+	fm := &fms2023.ForzaMotorsportHandler{}
+	require.NoError(b, fm.LoadFormats())
+	packet := recordedPackets(b)[0][:331]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fm.ProcessBuffer(server.Packet{Data: packet}, 1234)
+	}
+}
+
+// BenchmarkForzaMotorsportHandler_ProcessChannel waits until the adapter has converted every packet,
+// so no packet is dropped and every decoded sample is released for the next packet
+func BenchmarkForzaMotorsportHandler_ProcessChannel(b *testing.B) {
+	adapter := releasingAdapter{converted: make(chan struct{})}
+	fm := &fms2023.ForzaMotorsportHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: []telemetry.ConverterInterface{adapter},
+		},
+	}
+	require.NoError(b, fm.LoadFormats())
+	packet := recordedPackets(b)[0][:331]
+
+	channel := make(chan server.Packet)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fm.ProcessChannel(context.Background(), channel, 1234)
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		channel <- server.Packet{Data: packet}
+		<-adapter.converted
+	}
+	close(channel)
+	<-done
+}
+
+// recordedPackets returns the packets recorded in forzamotorsport.udp.log, one base64 encoded buffer per line
+func recordedPackets(t testing.TB) [][]byte {
+	t.Helper()
+
+	lines, err := telemetry.ReadLines("fms2023/forzamotorsport.udp.log")
+	require.NoError(t, err)
+
+	packets := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		packet, err := base64.StdEncoding.DecodeString(line)
+		require.NoError(t, err)
+		packets = append(packets, packet)
+	}
+	return packets
+}
+
+// releasingAdapter releases the data as the real adapters do, so the decoded samples are reused.
+// Every released data is signalled on converted, when it is set.
+type releasingAdapter struct {
+	converted chan struct{}
+}
+
+func (r releasingAdapter) ChannelInit(ctx context.Context, _ time.Time, channel chan telemetry.GameData, _ int) {
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		data.Release()
+		if r.converted != nil {
+			r.converted <- struct{}{}
+		}
+	})
+}
+
+func (releasingAdapter) Convert(time.Time, telemetry.GameData, int) {}
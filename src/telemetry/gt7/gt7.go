@@ -0,0 +1,272 @@
+package gt7
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"io/fs"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/salsa20"
+)
+
+const (
+	// DataFormatFile describes the decrypted 296 bytes packet
+	DataFormatFile = "gt7"
+	// FormatsDirEnvKey is the environment variable with the directory of the GT7 format files
+	FormatsDirEnvKey = "TMD_GT7_FORMATS"
+	// PacketSize is the size of the encrypted packet
+	PacketSize = 296
+	// Magic is the first field of every decrypted packet, "G7S0"
+	Magic = 0x47375330
+	// DefaultHeartbeatPort is the PlayStation port waiting for the heartbeat
+	DefaultHeartbeatPort = 33739
+	// DefaultHeartbeatInterval is the time between heartbeats, GT7 stops sending data without them
+	DefaultHeartbeatInterval = time.Second
+)
+
+// Flags bits
+const (
+	FlagCarOnTrack = 1 << 0
+	FlagPaused     = 1 << 1
+	FlagLoading    = 1 << 2
+)
+
+var (
+	ErrInvalidPacketSize = errors.WithMessage(telemetry.ErrPacketTooShort, "[GT7]")
+	ErrInvalidMagic      = errors.New("[GT7] invalid magic number")
+)
+
+// salsaKey is the first 32 bytes of "Simulator Interface Packet GT7 ver 0.0"
+var salsaKey = [32]byte([]byte("Simulator Interface Packet GT7 ver 0.0")[:32])
+
+// heartbeat is the packet sent to the PlayStation to keep the telemetry stream alive
+var heartbeat = []byte("A")
+
+// derivedKeys are the channels calculated from the decoded fields
+var derivedKeys = []string{"IsRaceOn", "CurrentGear", "SuggestedGear"}
+
+//go:embed gt7
+var formatFiles embed.FS
+
+// decryptBuffers contains the buffers the packets are decrypted into
+var decryptBuffers = sync.Pool{
+	New: func() any { return new([PacketSize]byte) },
+}
+
+// GT7Handler receives the Gran Turismo 7 telemetry.
+// GT7 sends the data only to the address sending the heartbeats, so the handler sends them periodically.
+type GT7Handler struct {
+	telemetry.TelemetryHandler
+	DebugMode         string
+	PlayStationIP     string
+	HeartbeatPort     int
+	HeartbeatInterval time.Duration
+	// Keys contains every channel published to the adapters
+	Keys   []string
+	schema *telemetry.Schema
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// NewGT7Handler creates a new GT7Handler sending the heartbeats to the PlayStation IP
+func NewGT7Handler(playStationIP, debugMode string) *GT7Handler {
+	return &GT7Handler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.GranTurismo7()),
+		},
+		DebugMode:         debugMode,
+		PlayStationIP:     playStationIP,
+		HeartbeatPort:     DefaultHeartbeatPort,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+	}
+}
+
+// InitAndRun starts the heartbeats and the GT7Handler
+func (gt *GT7Handler) InitAndRun(ctx context.Context, port int) error {
+	err := gt.LoadFormats()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go gt.sendHeartbeats(ctx)
+
+	udpServer := server.NewServer("0.0.0.0:" + strconv.Itoa(port))
+
+	log.Printf(
+		"GT7 server listening on %s:%d, sending heartbeats to %s:%d...\n",
+		telemetry.GetOutboundIP(), port, gt.PlayStationIP, gt.HeartbeatPort,
+	)
+
+	return udpServer.Run(ctx, gt.ProcessChannel, port)
+}
+
+// LoadFormats loads the packet format and builds the list of published channels
+func (gt *GT7Handler) LoadFormats() error {
+	schema, err := telemetry.LoadSchema(FormatsFS(), DataFormatFile)
+	if err != nil {
+		return err
+	}
+	gt.schema = schema
+	gt.Keys = telemetry.MergeKeys(derivedKeys, schema.Keys)
+
+	return nil
+}
+
+func (gt *GT7Handler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	gt.ProcessPackets(channel, port, gt.ProcessBuffer)
+}
+
+// ProcessBuffer decrypts and decodes the received data
+func (gt *GT7Handler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	// the decrypted packet is only read while decoding, so its buffer is reused for the next packets
+	decrypted := decryptBuffers.Get().(*[PacketSize]byte)
+	defer decryptBuffers.Put(decrypted)
+	if err := DecryptInto(decrypted[:], buffer); err != nil {
+		return err
+	}
+	if err := gt.schema.Check(decrypted[:]); err != nil {
+		return err
+	}
+
+	decoded := gt.samples.Get()
+	decoded.Buffer = buffer
+	gt.schema.DecodeValuesInto(decrypted[:], decoded.Values)
+	values := decoded.Data
+	telemetry.FloatsInto(decoded.Values, values)
+
+	flags := int(values["Flags"])
+	values["IsRaceOn"] = 0
+	if flags&FlagCarOnTrack != 0 && flags&(FlagPaused|FlagLoading) == 0 {
+		values["IsRaceOn"] = 1
+	}
+	gears := decrypted[gt.schema.Telemetries["Gears"].StartOffset]
+	values["CurrentGear"] = float32(gears & 0x0f)
+	values["SuggestedGear"] = float32(gears >> 4)
+
+	decoded.Normalized = normalize(values)
+
+	published := decoded.GameData(gt.Keys, packet.Source)
+	published.Normalized = &decoded.Normalized
+	gt.Bus.Publish(published)
+	return nil
+}
+
+// normalize maps the decoded values to the normalized sample.
+// The game sends the reverse as the gear 0 and does not send the neutral or the race position.
+func normalize(values map[string]float32) telemetry.Normalized {
+	gear := int(values["CurrentGear"])
+	if gear == 0 {
+		gear = -1
+	}
+
+	return telemetry.Normalized{
+		IsRaceOn:           values["IsRaceOn"] != 0,
+		IsPlayer:           true,
+		Speed:              values["MetersPerSecond"],
+		RPM:                values["EngineRPM"],
+		MaxRPM:             values["MaxAlertRPM"],
+		Gear:               gear,
+		Throttle:           values["Throttle"] / 255,
+		Brake:              values["Brake"] / 255,
+		Clutch:             values["ClutchPedal"],
+		Fuel:               telemetry.Ratio(values["GasLevel"], values["GasCapacity"]),
+		Position:           telemetry.VectorOf(values, "Position"),
+		Velocity:           telemetry.VectorOf(values, "Velocity"),
+		WheelSpeed:         telemetry.WheelsOf(values, "WheelRevPerSecond"),
+		TireTemperature:    telemetry.WheelsOf(values, "TireSurfaceTemperature"),
+		HasTireTemperature: true,
+		SuspensionTravel:   telemetry.WheelsOf(values, "SuspensionHeight"),
+		LapNumber:          max(int(values["LapCount"])-1, 0),
+		LastLap:            max(values["LastLapTime"], 0) / 1000,
+		BestLap:            max(values["BestLapTime"], 0) / 1000,
+		CarID:              int(values["CarCode"]),
+	}
+}
+
+// sendHeartbeats sends the heartbeat to the PlayStation until ctx is cancelled
+func (gt *GT7Handler) sendHeartbeats(ctx context.Context) {
+	address := net.JoinHostPort(gt.PlayStationIP, strconv.Itoa(gt.HeartbeatPort))
+	connection, err := net.Dial("udp", address)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer connection.Close()
+
+	ticker := time.NewTicker(gt.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		if _, err = connection.Write(heartbeat); err != nil {
+			telemetry.DisplayLog("vvv", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Decrypt decrypts the packet and validates the magic number
+func Decrypt(buffer []byte) ([]byte, error) {
+	decrypted := make([]byte, PacketSize)
+	if err := DecryptInto(decrypted, buffer); err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
+
+// DecryptInto decrypts the packet into decrypted, which holds at least PacketSize bytes, and validates the magic number
+func DecryptInto(decrypted, buffer []byte) error {
+	if len(buffer) < PacketSize {
+		return errors.Wrapf(ErrInvalidPacketSize, "%d bytes", len(buffer))
+	}
+
+	iv := nonce(buffer)
+	salsa20.XORKeyStream(decrypted[:PacketSize], buffer[:PacketSize], iv[:], &salsaKey)
+
+	if binary.LittleEndian.Uint32(decrypted[0:4]) != Magic {
+		return ErrInvalidMagic
+	}
+	return nil
+}
+
+// Encrypt encrypts the decrypted packet the same way the PlayStation does, used to replay recorded packets.
+// The nonce is taken from the packet bytes 0x40-0x44, which are sent unencrypted.
+func Encrypt(packet []byte) []byte {
+	encrypted := make([]byte, PacketSize)
+	iv := nonce(packet)
+	salsa20.XORKeyStream(encrypted, packet[:PacketSize], iv[:], &salsaKey)
+	copy(encrypted[0x40:0x44], packet[0x40:0x44])
+	return encrypted
+}
+
+// nonce builds the Salsa20 nonce from the packet IV
+func nonce(buffer []byte) [8]byte {
+	iv1 := binary.LittleEndian.Uint32(buffer[0x40:0x44])
+	iv2 := iv1 ^ 0xDEADBEAF
+
+	var nonce [8]byte
+	binary.LittleEndian.PutUint32(nonce[0:4], iv2)
+	binary.LittleEndian.PutUint32(nonce[4:8], iv1)
+	return nonce
+}
+
+// FormatsFS returns the GT7 format files, TMD_GT7_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
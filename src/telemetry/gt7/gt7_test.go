@@ -0,0 +1,112 @@
+package gt7_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/gt7"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecrypt(t *testing.T) {
+	packet := recordedPacket()
+	encrypted := gt7.Encrypt(packet)
+	assert.NotEqual(t, packet[:0x40], encrypted[:0x40])
+
+	decrypted, err := gt7.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, packet[:0x40], decrypted[:0x40])
+	assert.Equal(t, packet[0x44:], decrypted[0x44:])
+
+	_, err = gt7.Decrypt(encrypted[:100])
+	assert.ErrorIs(t, err, gt7.ErrInvalidPacketSize)
+
+	_, err = gt7.Decrypt(packet)
+	assert.ErrorIs(t, err, gt7.ErrInvalidMagic)
+}
+
+func TestDecryptInto(t *testing.T) {
+	encrypted := gt7.Encrypt(recordedPacket())
+	decrypted := make([]byte, gt7.PacketSize)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		require.NoError(t, gt7.DecryptInto(decrypted, encrypted))
+	})
+	assert.Zero(t, allocs)
+	assert.Equal(t, uint32(gt7.Magic), binary.LittleEndian.Uint32(decrypted[0:4]))
+}
+
+func TestGT7Handler_FakeConsole(t *testing.T) {
+	console, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer console.Close()
+
+	adapter := &test.RecordingAdapter{}
+	handler := gt7.NewGT7Handler("127.0.0.1", "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+	handler.HeartbeatPort = console.LocalAddr().(*net.UDPAddr).Port
+	handler.HeartbeatInterval = 20 * time.Millisecond
+	port := test.FreePort(t)
+
+	go func() {
+		_ = handler.InitAndRun(context.Background(), port)
+	}()
+
+	// the fake console answers every heartbeat with an encrypted packet
+	go func() {
+		buffer := make([]byte, 16)
+		for {
+			n, _, err := console.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			if string(buffer[:n]) != "A" {
+				continue
+			}
+			address := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+			_, _ = console.WriteToUDP(gt7.Encrypt(recordedPacket()), address)
+		}
+	}()
+
+	require.Eventually(t, func() bool { return adapter.Count() > 0 }, 2*time.Second, 10*time.Millisecond)
+	data := adapter.First()
+
+	assert.Equal(t, handler.Keys, data.Keys)
+	assert.Equal(t, float32(1), data.Data["IsRaceOn"])
+	assert.Equal(t, float32(6543), data.Data["EngineRPM"])
+	assert.Equal(t, float32(45.5), data.Data["MetersPerSecond"])
+	assert.Equal(t, float32(3), data.Data["LapCount"])
+	assert.Equal(t, float32(4), data.Data["CurrentGear"])
+	assert.Equal(t, float32(5), data.Data["SuggestedGear"])
+	assert.Equal(t, float32(255), data.Data["Throttle"])
+	assert.Equal(t, float32(3317), data.Data["CarCode"])
+
+	require.NotNil(t, data.Normalized)
+	assert.Equal(t, float32(45.5), data.Normalized.Speed)
+	assert.Equal(t, 4, data.Normalized.Gear)
+	assert.Equal(t, float32(1), data.Normalized.Throttle)
+	assert.Equal(t, 2, data.Normalized.LapNumber)
+	assert.Equal(t, 3317, data.Normalized.CarID)
+}
+
+// recordedPacket returns a decrypted packet as sent by the console
+func recordedPacket() []byte {
+	packet := make([]byte, gt7.PacketSize)
+	binary.LittleEndian.PutUint32(packet[0x00:], gt7.Magic)
+	binary.LittleEndian.PutUint32(packet[0x3C:], math.Float32bits(6543))
+	binary.LittleEndian.PutUint32(packet[0x40:], 0x12345678)
+	binary.LittleEndian.PutUint32(packet[0x4C:], math.Float32bits(45.5))
+	binary.LittleEndian.PutUint16(packet[0x74:], 3)
+	binary.LittleEndian.PutUint16(packet[0x8E:], gt7.FlagCarOnTrack)
+	packet[0x90] = 0x54
+	packet[0x91] = 255
+	binary.LittleEndian.PutUint32(packet[0x124:], 3317)
+	return packet
+}
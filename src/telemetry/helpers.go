@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"bufio"
+	"io/fs"
 	"log"
 	"net"
 	"os"
@@ -42,3 +43,12 @@ func ReadLines(path string) ([]string, error) {
 	}
 	return lines, scanner.Err()
 }
+
+// FormatsFS returns the file system with the packet format files of a game.
+// The built-in formats are used unless the envKey environment variable points to a directory with the format files.
+func FormatsFS(builtIn fs.FS, envKey string) fs.FS {
+	if dir := os.Getenv(envKey); dir != "" {
+		return os.DirFS(dir)
+	}
+	return builtIn
+}
@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ListenerMetrics are the pipeline counters of a listener, exported by the metrics adapters
+type ListenerMetrics struct {
+	Port int
+	// Received is the number of packets passed to the decoder, Rejected the rejected packets by the reason
+	Received uint64
+	Rejected map[string]uint64
+	Adapters []AdapterMetrics
+}
+
+// AdapterMetrics are the counters of an adapter queue of the Bus
+type AdapterMetrics struct {
+	// Adapter is the adapter type, eg. *converter.CsvConverter
+	Adapter   string
+	Published uint64
+	Dropped   uint64
+}
+
+// listeners contains the handlers and the buses of the running listeners by the port
+var listeners = struct {
+	mu       sync.Mutex
+	handlers map[int]*TelemetryHandler
+	buses    map[int]*Bus
+}{
+	handlers: map[int]*TelemetryHandler{},
+	buses:    map[int]*Bus{},
+}
+
+// Metrics returns the counters of every listener, ordered by the port
+func Metrics() []ListenerMetrics {
+	listeners.mu.Lock()
+	defer listeners.mu.Unlock()
+
+	ports := map[int]bool{}
+	for port := range listeners.handlers {
+		ports[port] = true
+	}
+	for port := range listeners.buses {
+		ports[port] = true
+	}
+
+	metrics := make([]ListenerMetrics, 0, len(ports))
+	for port := range ports {
+		listener := ListenerMetrics{Port: port, Rejected: map[string]uint64{}}
+		if handler, ok := listeners.handlers[port]; ok {
+			listener.Received = handler.Received.Load()
+			listener.Rejected = handler.Rejects.Counts()
+		}
+		if bus, ok := listeners.buses[port]; ok {
+			for _, sub := range bus.subscribers {
+				listener.Adapters = append(listener.Adapters, AdapterMetrics{
+					Adapter:   fmt.Sprintf("%T", sub.adapter),
+					Published: sub.published.Load(),
+					Dropped:   sub.dropped.Load(),
+				})
+			}
+		}
+		metrics = append(metrics, listener)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Port < metrics[j].Port })
+	return metrics
+}
+
+func registerHandler(port int, handler *TelemetryHandler) {
+	listeners.mu.Lock()
+	defer listeners.mu.Unlock()
+	listeners.handlers[port] = handler
+}
+
+func registerBus(port int, bus *Bus) {
+	listeners.mu.Lock()
+	defer listeners.mu.Unlock()
+	listeners.buses[port] = bus
+}
+
+// unregisterBus removes the stopped bus, unless the port is used by a new bus already
+func unregisterBus(port int, bus *Bus) {
+	listeners.mu.Lock()
+	defer listeners.mu.Unlock()
+	if listeners.buses[port] == bus {
+		delete(listeners.buses, port)
+	}
+}
@@ -0,0 +1,108 @@
+package telemetry
+
+// StandardGravity converts the G forces to m/s²
+const StandardGravity = 9.80665
+
+// Wheel indexes of the Wheels arrays
+const (
+	FrontLeft = iota
+	FrontRight
+	RearLeft
+	RearRight
+)
+
+// WheelSuffixes are the suffixes of the per wheel fields in the format files
+var WheelSuffixes = [4]string{"FrontLeft", "FrontRight", "RearLeft", "RearRight"}
+
+// Vector3 is a value in the game world coordinates, Y is up when the game sends the height
+type Vector3 struct {
+	X, Y, Z float32
+}
+
+// Wheels contains a value of every wheel in the FrontLeft, FrontRight, RearLeft, RearRight order
+type Wheels [4]float32
+
+// Normalized is the game-agnostic telemetry sample. Every game decoder maps its values into it,
+// so the adapters can be written once for all games. Values not sent by the game are zero.
+// The units are metres, seconds, radians and degrees Celsius.
+type Normalized struct {
+	IsRaceOn bool
+	// IsPlayer is false for the data of the other cars, when the game sends the data of every car
+	IsPlayer bool
+
+	// Speed in m/s
+	Speed   float32
+	RPM     float32
+	MaxRPM  float32
+	IdleRPM float32
+	// Gear is -1 in the reverse and 0 in the neutral
+	Gear int
+
+	// Throttle, Brake, Clutch and HandBrake are from 0 to 1, Steer from -1 (left) to 1 (right)
+	Throttle  float32
+	Brake     float32
+	Clutch    float32
+	HandBrake float32
+	Steer     float32
+	// Fuel left as the fraction of the tank
+	Fuel float32
+
+	Position Vector3
+	// Velocity in m/s and Acceleration in m/s²
+	Velocity     Vector3
+	Acceleration Vector3
+	Yaw          float32
+	Pitch        float32
+	Roll         float32
+
+	// WheelSpeed in rad/s
+	WheelSpeed       Wheels
+	TireTemperature  Wheels
+	SuspensionTravel Wheels
+	// HasTireTemperature is set by the games sending the tire temperatures, to tell them from 0 °C
+	HasTireTemperature bool
+
+	// LapNumber is the number of completed laps, or the completed stages in the rally games
+	LapNumber    int
+	RacePosition int
+	// CurrentLap, LastLap and BestLap lap times in seconds
+	CurrentLap float32
+	LastLap    float32
+	BestLap    float32
+	// Distance driven in the session in metres
+	Distance float32
+
+	CarID    int
+	CarClass int
+	TrackID  int
+}
+
+// VectorOf reads the prefixX, prefixY and prefixZ values
+func VectorOf(values map[string]float32, prefix string) Vector3 {
+	return Vector3{X: values[prefix+"X"], Y: values[prefix+"Y"], Z: values[prefix+"Z"]}
+}
+
+// WheelsOf reads the prefixFrontLeft, prefixFrontRight, prefixRearLeft and prefixRearRight values
+func WheelsOf(values map[string]float32, prefix string) Wheels {
+	var wheels Wheels
+	for i, suffix := range WheelSuffixes {
+		wheels[i] = values[prefix+suffix]
+	}
+	return wheels
+}
+
+// Scale multiplies the value of every wheel
+func (w Wheels) Scale(scale float32) Wheels {
+	for i := range w {
+		w[i] *= scale
+	}
+	return w
+}
+
+// Ratio returns the fraction of the capacity, 0 when the capacity is unknown
+func Ratio(value, capacity float32) float32 {
+	if capacity == 0 {
+		return 0
+	}
+	return value / capacity
+}
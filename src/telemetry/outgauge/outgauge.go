@@ -0,0 +1,253 @@
+package outgauge
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"log"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OutGaugeFormatFile describes the OutGauge (dashboard) packet
+	OutGaugeFormatFile = "outgauge"
+	// OutSimFormatFile describes the OutSim (motion) packet
+	OutSimFormatFile = "outsim"
+	// IDSize is the size of the optional ID sent at the end of both packets
+	IDSize = 4
+	// PositionScale converts the OutSim position to metres
+	PositionScale = 65536
+	// DefaultMaxSampleAge is the maximum time difference of the merged OutGauge and OutSim samples
+	DefaultMaxSampleAge = 100 * time.Millisecond
+	// FormatsDirEnvKey is the environment variable with the directory of the OutGauge and OutSim format files
+	FormatsDirEnvKey = "TMD_OUTGAUGE_FORMATS"
+)
+
+var ErrUnknownPacket = errors.WithMessage(telemetry.ErrUnknownFormat, "[OutGauge]")
+
+//go:embed outgauge outsim
+var formatFiles embed.FS
+
+// OutGaugeHandler receives the OutGauge and OutSim packets sent by Live for Speed and BeamNG.drive.
+// Both packets can be sent to the same port or to separate ports, every OutGauge sample is published
+// merged with the OutSim sample closest in time.
+type OutGaugeHandler struct {
+	telemetry.TelemetryHandler
+	Game      enums.Game
+	DebugMode string
+	// OutSimPort is the port of the OutSim packets, 0 when they are sent to the OutGauge port
+	OutSimPort   int
+	MaxSampleAge time.Duration
+	// Keys contains every channel published to the adapters
+	Keys    []string
+	gauge   *telemetry.Schema
+	sim     *telemetry.Schema
+	sims    *telemetry.Sessions[*simSample]
+	started time.Time
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// simSample is the last OutSim sample of a game, merged to its next OutGauge sample
+type simSample struct {
+	values map[string]telemetry.Value
+	time   int64
+}
+
+// NewLiveForSpeedHandler creates a new OutGaugeHandler for Live for Speed
+func NewLiveForSpeedHandler(outSimPort int, debugMode string) *OutGaugeHandler {
+	return newOutGaugeHandler(enums.Games.LiveForSpeed(), outSimPort, debugMode)
+}
+
+// NewBeamNGHandler creates a new OutGaugeHandler for BeamNG.drive
+func NewBeamNGHandler(outSimPort int, debugMode string) *OutGaugeHandler {
+	return newOutGaugeHandler(enums.Games.BeamNG(), outSimPort, debugMode)
+}
+
+func newOutGaugeHandler(game enums.Game, outSimPort int, debugMode string) *OutGaugeHandler {
+	return &OutGaugeHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(game),
+		},
+		Game:         game,
+		DebugMode:    debugMode,
+		OutSimPort:   outSimPort,
+		MaxSampleAge: DefaultMaxSampleAge,
+	}
+}
+
+// InitAndRun listens for the OutGauge packets on the port and for the OutSim packets on the OutSimPort.
+// When one of the servers stops the other one is stopped too.
+func (og *OutGaugeHandler) InitAndRun(ctx context.Context, port int) error {
+	err := og.LoadFormats()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	packets := make(chan server.Packet)
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		og.ProcessChannel(ctx, packets, port)
+	}()
+	forward := func(_ context.Context, channel chan server.Packet, _ int) {
+		for packet := range channel {
+			packets <- packet
+		}
+	}
+
+	var servers sync.WaitGroup
+	errs := make(chan error, 2)
+	run := func(udpServer server.Server, port int) {
+		servers.Add(1)
+		go func() {
+			defer servers.Done()
+			errs <- udpServer.Run(ctx, forward, port)
+		}()
+	}
+	run(server.NewServer("0.0.0.0:"+strconv.Itoa(port)), port)
+	if og.OutSimPort != 0 && og.OutSimPort != port {
+		run(server.NewServer("0.0.0.0:"+strconv.Itoa(og.OutSimPort)), og.OutSimPort)
+	}
+
+	log.Printf(
+		"[%s] UDP server listening on %s, OutGauge port %d, OutSim port %d...\n",
+		og.Game, telemetry.GetOutboundIP(), port, og.OutSimPort,
+	)
+
+	err = <-errs
+	cancel()
+	servers.Wait()
+	close(packets)
+	<-processed
+	return err
+}
+
+// LoadFormats loads the packet formats and builds the list of published channels
+func (og *OutGaugeHandler) LoadFormats() error {
+	var err error
+	og.gauge, err = telemetry.LoadSchema(FormatsFS(), OutGaugeFormatFile)
+	if err != nil {
+		return err
+	}
+	og.sim, err = telemetry.LoadSchema(FormatsFS(), OutSimFormatFile)
+	if err != nil {
+		return err
+	}
+
+	og.Keys = telemetry.MergeKeys([]string{"IsRaceOn"}, og.gauge.Keys, og.sim.Keys)
+	og.sims = telemetry.NewSessions(func() *simSample { return &simSample{} })
+	og.started = time.Now()
+	return nil
+}
+
+// ProcessChannel processes the OutGauge and OutSim packets received on both ports
+func (og *OutGaugeHandler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	og.ProcessPackets(channel, port, og.ProcessBuffer)
+}
+
+// ProcessBuffer recognizes the packet by its size, with or without the optional ID.
+// The OutSim sample is stored until the next OutGauge sample of the same host is published,
+// the game sends both packets from separate sockets so the port of the source is ignored.
+func (og *OutGaugeHandler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	sim := og.sims.Get(netip.AddrPortFrom(packet.Source.Addr(), 0))
+	switch len(buffer) {
+	case og.sim.Size, og.sim.Size + IDSize:
+		// the sample is copied to the published data, so its map is reused for the next sample
+		if sim.values == nil {
+			sim.values = map[string]telemetry.Value{}
+		}
+		og.sim.DecodeValuesInto(buffer[:og.sim.Size], sim.values)
+		sim.time = og.sampleTime(sim.values, "OutSimTime")
+		sim.values["OutSimTime"] = telemetry.Value{DataType: "U32", Int: sim.time}
+		server.ReleaseBuffer(buffer)
+	case og.gauge.Size, og.gauge.Size + IDSize:
+		decoded := og.samples.Get()
+		decoded.Buffer = buffer
+		values := decoded.Values
+		og.gauge.DecodeValuesInto(buffer[:og.gauge.Size], values)
+		sampleTime := og.sampleTime(values, "Time")
+		values["Time"] = telemetry.Value{DataType: "U32", Int: sampleTime}
+		if sim.values != nil && abs(sampleTime-sim.time) <= og.MaxSampleAge.Milliseconds() {
+			for key, value := range sim.values {
+				values[key] = value
+			}
+		}
+
+		data := decoded.Data
+		telemetry.FloatsInto(values, data)
+		// the channels calculated from the packets are only in Data
+		data["IsRaceOn"] = 1
+		data["Gear"]--
+		delete(values, "Gear")
+		for _, axis := range []string{"PositionX", "PositionY", "PositionZ"} {
+			if _, ok := values[axis]; ok {
+				data[axis] /= PositionScale
+				delete(values, axis)
+			}
+		}
+
+		decoded.Normalized = normalize(data)
+
+		published := decoded.GameData(og.Keys, packet.Source)
+		published.Normalized = &decoded.Normalized
+		og.Bus.Publish(published)
+	default:
+		return errors.Wrapf(ErrUnknownPacket, "%d bytes", len(buffer))
+	}
+	return nil
+}
+
+// normalize maps the OutGauge values merged with the OutSim sample to the normalized sample
+func normalize(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn:     values["IsRaceOn"] != 0,
+		IsPlayer:     true,
+		Speed:        values["Speed"],
+		RPM:          values["RPM"],
+		Gear:         int(values["Gear"]),
+		Throttle:     values["Throttle"],
+		Brake:        values["Brake"],
+		Clutch:       values["Clutch"],
+		Fuel:         values["Fuel"],
+		Position:     telemetry.VectorOf(values, "Position"),
+		Velocity:     telemetry.VectorOf(values, "Velocity"),
+		Acceleration: telemetry.VectorOf(values, "Acceleration"),
+		Yaw:          values["Heading"],
+		Pitch:        values["Pitch"],
+		Roll:         values["Roll"],
+	}
+}
+
+// sampleTime returns the game time of the sample in milliseconds,
+// BeamNG does not send the time so the time since the start is used instead
+func (og *OutGaugeHandler) sampleTime(values map[string]telemetry.Value, key string) int64 {
+	if values[key].Int != 0 {
+		return values[key].Int
+	}
+	return time.Since(og.started).Milliseconds()
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// FormatsFS returns the OutGauge and OutSim format files, TMD_OUTGAUGE_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
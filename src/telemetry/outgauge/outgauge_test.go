@@ -0,0 +1,120 @@
+package outgauge_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/outgauge"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutGaugeHandler_MergesSamples(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	handler := outgauge.NewLiveForSpeedHandler(0, "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+	require.NoError(t, handler.LoadFormats())
+	channel := make(chan server.Packet)
+	go handler.ProcessChannel(context.Background(), channel, 30000)
+
+	channel <- server.Packet{Data: outSimPacket(1000, false)}
+	channel <- server.Packet{Data: outGaugePacket(1040, true)}
+	// the OutSim sample is too old
+	channel <- server.Packet{Data: outGaugePacket(1500, false)}
+
+	require.Eventually(t, func() bool { return adapter.Count() == 2 }, time.Second, 10*time.Millisecond)
+	data := adapter.All()
+
+	merged := data[0].Data
+	assert.Equal(t, handler.Keys, data[0].Keys)
+	assert.Equal(t, float32(1), merged["IsRaceOn"])
+	assert.Equal(t, float32(1040), merged["Time"])
+	assert.Equal(t, float32(1000), merged["OutSimTime"])
+	assert.Equal(t, float32(3), merged["Gear"])
+	assert.Equal(t, float32(42.5), merged["Speed"])
+	assert.Equal(t, float32(6800), merged["RPM"])
+	assert.Equal(t, float32(1.25), merged["AccelerationX"])
+	assert.Equal(t, float32(12.5), merged["PositionX"])
+
+	require.NotNil(t, data[0].Normalized)
+	assert.Equal(t, 3, data[0].Normalized.Gear)
+	assert.Equal(t, float32(42.5), data[0].Normalized.Speed)
+	assert.Equal(t, float32(12.5), data[0].Normalized.Position.X)
+
+	assert.Equal(t, float32(1500), data[1].Data["Time"])
+	assert.Equal(t, telemetry.Value{DataType: "U32", Int: 1500}, data[1].Values["Time"])
+	assert.Equal(t, telemetry.Value{DataType: "F32", Float: 42.5}, data[0].Values["Speed"])
+	assert.NotContains(t, data[0].Values, "Gear", "the calculated channels are only in Data")
+	assert.NotContains(t, data[0].Values, "PositionX", "the calculated channels are only in Data")
+	assert.NotContains(t, data[1].Data, "OutSimTime")
+
+	assert.ErrorIs(t, handler.ProcessBuffer(server.Packet{Data: make([]byte, 10)}, 30000), outgauge.ErrUnknownPacket)
+}
+
+func TestOutGaugeHandler_SeparatePorts(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	gaugePort, simPort := test.FreePort(t), test.FreePort(t)
+	handler := outgauge.NewBeamNGHandler(simPort, "")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+
+	go func() {
+		_ = handler.InitAndRun(context.Background(), gaugePort)
+	}()
+
+	gauge := test.Dial(t, gaugePort)
+	sim := test.Dial(t, simPort)
+
+	// BeamNG does not send the time, the samples are merged by the time of receiving them
+	require.Eventually(t, func() bool {
+		_, _ = sim.Write(outSimPacket(0, true))
+		time.Sleep(5 * time.Millisecond)
+		_, _ = gauge.Write(outGaugePacket(0, false))
+
+		for _, data := range adapter.All() {
+			if _, ok := data.Data["OutSimTime"]; ok {
+				return true
+			}
+		}
+		return false
+	}, 2*time.Second, 20*time.Millisecond)
+
+	for _, data := range adapter.All() {
+		if _, ok := data.Data["OutSimTime"]; ok {
+			assert.Equal(t, float32(42.5), data.Data["Speed"])
+			assert.Equal(t, float32(1.25), data.Data["AccelerationX"])
+			assert.Greater(t, data.Data["Time"], float32(0))
+		}
+	}
+}
+
+func outGaugePacket(gameTime uint32, withID bool) []byte {
+	size := 92
+	if withID {
+		size += outgauge.IDSize
+	}
+	packet := make([]byte, size)
+	binary.LittleEndian.PutUint32(packet[0:], gameTime)
+	copy(packet[4:], "XRT")
+	packet[10] = 4 // third gear
+	binary.LittleEndian.PutUint32(packet[12:], math.Float32bits(42.5))
+	binary.LittleEndian.PutUint32(packet[16:], math.Float32bits(6800))
+	return packet
+}
+
+func outSimPacket(gameTime uint32, withID bool) []byte {
+	size := 64
+	if withID {
+		size += outgauge.IDSize
+	}
+	packet := make([]byte, size)
+	binary.LittleEndian.PutUint32(packet[0:], gameTime)
+	binary.LittleEndian.PutUint32(packet[28:], math.Float32bits(1.25))
+	binary.LittleEndian.PutUint32(packet[52:], uint32(12.5*outgauge.PositionScale))
+	return packet
+}
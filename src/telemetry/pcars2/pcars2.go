@@ -0,0 +1,371 @@
+package pcars2
+
+import (
+	"context"
+	"embed"
+	"encoding/binary"
+	"io/fs"
+	"log"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderSize is the size of the header sent at the beginning of every packet
+	HeaderSize = 12
+	// MaxParticipants is the number of participants sent in the timings and time stats packets
+	MaxParticipants = 32
+	// ParticipantsPerPacket is the number of participants sent in a single participants packet
+	ParticipantsPerPacket = 16
+	// NameLength is the length of the participant name
+	NameLength = 64
+	// FormatsDirEnvKey is the environment variable with the directory of the Project CARS 2 format files
+	FormatsDirEnvKey = "TMD_PCARS2_FORMATS"
+)
+
+// Packet types of the SMS UDP v2 protocol
+const (
+	PacketCarPhysics              uint8 = 0
+	PacketRaceDefinition          uint8 = 1
+	PacketParticipants            uint8 = 2
+	PacketTimings                 uint8 = 3
+	PacketGameState               uint8 = 4
+	PacketWeatherState            uint8 = 5
+	PacketVehicleNames            uint8 = 6
+	PacketTimeStats               uint8 = 7
+	PacketParticipantVehicleNames uint8 = 8
+)
+
+// GameStatePlaying is the game state while driving, not paused and not in the menu
+const GameStatePlaying = 2
+
+// Format files of the supported packets. Participant formats describe the data of a single participant.
+const (
+	TelemetryFormatFile        = "telemetry"
+	RaceDataFormatFile         = "racedata"
+	GameStateFormatFile        = "gamestate"
+	TimingsFormatFile          = "timings"
+	ParticipantInfoFormatFile  = "participantinfo"
+	ParticipantStatsFormatFile = "participantstats"
+)
+
+var ErrPacketTooShort = errors.WithMessage(telemetry.ErrPacketTooShort, "[PCARS2]")
+
+//go:embed telemetry racedata gamestate timings participantinfo participantstats
+var formatFiles embed.FS
+
+// derivedKeys are the channels calculated from the bit fields and the player participant
+var derivedKeys = []string{
+	"IsRaceOn", "PlayerParticipantIndex", "GameState", "SessionState", "Gear", "NumGears",
+	"RacePosition", "Sector", "RaceState", "LapInvalidated",
+}
+
+// Header is sent at the beginning of every packet
+type Header struct {
+	PacketNumber         uint32
+	CategoryPacketNumber uint32
+	PartialPacketIndex   uint8
+	PartialPacketNumber  uint8
+	PacketType           uint8
+	PacketVersion        uint8
+}
+
+// ParseHeader reads the packet header
+func ParseHeader(buffer []byte) (Header, error) {
+	if len(buffer) < HeaderSize {
+		return Header{}, errors.Wrapf(ErrPacketTooShort, "header: %d bytes", len(buffer))
+	}
+
+	return Header{
+		PacketNumber:         binary.LittleEndian.Uint32(buffer[0:4]),
+		CategoryPacketNumber: binary.LittleEndian.Uint32(buffer[4:8]),
+		PartialPacketIndex:   buffer[8],
+		PartialPacketNumber:  buffer[9],
+		PacketType:           buffer[10],
+		PacketVersion:        buffer[11],
+	}, nil
+}
+
+// PCars2Handler decodes the Project CARS 2 and Automobilista 2 UDP packets (SMS UDP v2, "Project CARS 2" format).
+// The packets of the player participant are merged and published on every telemetry packet.
+type PCars2Handler struct {
+	telemetry.TelemetryHandler
+	DebugMode string
+	// Keys contains every channel published to the adapters
+	Keys     []string
+	formats  map[string]*telemetry.Schema
+	sessions *telemetry.Sessions[*Session]
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// Session is the state of a game sending to the port
+type Session struct {
+	// Names contains the participant names, indexed by the participant index
+	Names       [MaxParticipants]string
+	playerIndex int
+	reassembler *Reassembler
+	player      map[string]telemetry.Value
+}
+
+// NewPCars2Handler creates a new PCars2Handler
+func NewPCars2Handler(debugMode string) *PCars2Handler {
+	return &PCars2Handler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.ProjectCars2()),
+		},
+		DebugMode: debugMode,
+	}
+}
+
+// InitAndRun starts the PCars2Handler
+func (pc *PCars2Handler) InitAndRun(ctx context.Context, port int) error {
+	err := pc.LoadFormats()
+	if err != nil {
+		return err
+	}
+
+	udpServer := server.NewServer("0.0.0.0:" + strconv.Itoa(port))
+
+	log.Printf(
+		"PCARS2 UDP server listening on %s:%d, waiting for PCARS2/AMS2 data...\n", telemetry.GetOutboundIP(), port,
+	)
+
+	return udpServer.Run(ctx, pc.ProcessChannel, port)
+}
+
+// LoadFormats loads the packet formats and builds the list of published channels
+func (pc *PCars2Handler) LoadFormats() error {
+	names := []string{
+		TelemetryFormatFile, RaceDataFormatFile, GameStateFormatFile,
+		TimingsFormatFile, ParticipantInfoFormatFile, ParticipantStatsFormatFile,
+	}
+
+	pc.formats = map[string]*telemetry.Schema{}
+	schemaKeys := [][]string{derivedKeys}
+	for _, name := range names {
+		schema, err := telemetry.LoadSchema(FormatsFS(), name)
+		if err != nil {
+			return err
+		}
+		pc.formats[name] = schema
+		schemaKeys = append(schemaKeys, schema.Keys)
+	}
+	keys := telemetry.MergeKeys(schemaKeys...)
+	pc.Keys = keys
+	pc.sessions = telemetry.NewSessions(func() *Session {
+		return &Session{
+			playerIndex: -1,
+			reassembler: NewReassembler(),
+			player:      make(map[string]telemetry.Value, len(keys)),
+		}
+	})
+
+	return nil
+}
+
+// Session returns the state of the game sending from the source
+func (pc *PCars2Handler) Session(source netip.AddrPort) *Session {
+	return pc.sessions.Get(source)
+}
+
+func (pc *PCars2Handler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	pc.ProcessPackets(channel, port, pc.ProcessBuffer)
+}
+
+// ProcessBuffer reassembles the packet and dispatches it by its type.
+// The buffers of the merged single part packets are reused, the telemetry packet buffer is released
+// with the published data. The parts of the split packets are kept by the reassembler, so they are not reused.
+func (pc *PCars2Handler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	header, err := ParseHeader(buffer)
+	if err != nil {
+		return err
+	}
+
+	session := pc.sessions.Get(packet.Source)
+	parts := session.reassembler.Add(header, buffer)
+	if parts == nil {
+		// a stale packet or a part of a packet which is not complete yet
+		return nil
+	}
+
+	switch header.PacketType {
+	case PacketCarPhysics:
+		err = pc.merge(session, TelemetryFormatFile, buffer, HeaderSize)
+		if err != nil {
+			return err
+		}
+		pc.publish(session, packet)
+		return nil
+	case PacketRaceDefinition:
+		err = pc.merge(session, RaceDataFormatFile, buffer, HeaderSize)
+	case PacketGameState:
+		err = pc.merge(session, GameStateFormatFile, buffer, HeaderSize)
+	case PacketTimings:
+		err = pc.mergeTimings(session, buffer)
+	case PacketTimeStats:
+		if session.playerIndex >= 0 {
+			// the participants changed timestamp precedes the participants
+			schema := pc.formats[ParticipantStatsFormatFile]
+			err = pc.merge(session, ParticipantStatsFormatFile, buffer, HeaderSize+4+session.playerIndex*schema.Size)
+		}
+	case PacketParticipants:
+		err = session.updateNames(parts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(parts) == 1 {
+		server.ReleaseBuffer(buffer)
+	}
+	return nil
+}
+
+// merge stores the data decoded from the packet at the offset
+func (pc *PCars2Handler) merge(session *Session, format string, buffer []byte, offset int) error {
+	schema := pc.formats[format]
+	if len(buffer) < offset+schema.Size {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d bytes", schema.Name, len(buffer))
+	}
+
+	schema.MergeValues(buffer[offset:offset+schema.Size], session.player)
+	return nil
+}
+
+// mergeTimings stores the timings and reads the player participant index,
+// which is sent after the timings of all participants
+func (pc *PCars2Handler) mergeTimings(session *Session, buffer []byte) error {
+	timings := pc.formats[TimingsFormatFile]
+	participant := pc.formats[ParticipantInfoFormatFile]
+	participantsOffset := HeaderSize + timings.Size
+	localIndexOffset := participantsOffset + MaxParticipants*participant.Size
+	if len(buffer) < localIndexOffset+2 {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d bytes", timings.Name, len(buffer))
+	}
+
+	err := pc.merge(session, TimingsFormatFile, buffer, HeaderSize)
+	if err != nil {
+		return err
+	}
+
+	playerIndex := int(int16(binary.LittleEndian.Uint16(buffer[localIndexOffset:])))
+	if playerIndex < 0 || playerIndex >= MaxParticipants {
+		// spectating, there is no player participant
+		session.playerIndex = -1
+		return nil
+	}
+	if playerIndex != session.playerIndex {
+		telemetry.DisplayLog("vvv", "Player participant "+strconv.Itoa(playerIndex)+" "+session.Names[playerIndex])
+	}
+	session.playerIndex = playerIndex
+
+	return pc.merge(session, ParticipantInfoFormatFile, buffer, participantsOffset+playerIndex*participant.Size)
+}
+
+// updateNames reads the participant names from the reassembled participants packet
+func (s *Session) updateNames(parts [][]byte) error {
+	// the participants changed timestamp precedes the names
+	namesOffset := HeaderSize + 4
+	for part, buffer := range parts {
+		if len(buffer) < namesOffset+ParticipantsPerPacket*NameLength {
+			return errors.Wrapf(ErrPacketTooShort, "participants: %d bytes", len(buffer))
+		}
+		for i := 0; i < ParticipantsPerPacket; i++ {
+			index := part*ParticipantsPerPacket + i
+			if index >= MaxParticipants {
+				break
+			}
+			name := buffer[namesOffset+i*NameLength : namesOffset+(i+1)*NameLength]
+			s.Names[index] = strings.TrimRight(string(name), "\x00")
+		}
+	}
+	return nil
+}
+
+// publish sends a copy of the merged player data to the adapters,
+// the channels calculated from the bit fields are only in Data
+func (pc *PCars2Handler) publish(session *Session, packet server.Packet) {
+	decoded := pc.samples.Get()
+	decoded.Buffer = packet.Data
+	for key, value := range session.player {
+		decoded.Values[key] = value
+	}
+	data := decoded.Data
+	telemetry.FloatsInto(decoded.Values, data)
+
+	gameSessionState := uint8(session.player["GameSessionState"].Int)
+	data["GameState"] = float32(gameSessionState & 0x07)
+	data["SessionState"] = float32(gameSessionState >> 4)
+	data["IsRaceOn"] = 0
+	if gameSessionState&0x07 == GameStatePlaying {
+		data["IsRaceOn"] = 1
+	}
+
+	gearNumGears := uint8(session.player["GearNumGears"].Int)
+	data["Gear"] = float32(gearNumGears & 0x0f)
+	if gearNumGears&0x0f == 0x0f {
+		// reverse
+		data["Gear"] = -1
+	}
+	data["NumGears"] = float32(gearNumGears >> 4)
+
+	data["PlayerParticipantIndex"] = float32(session.playerIndex)
+	data["RacePosition"] = float32(uint8(session.player["RacePositionFlags"].Int) & 0x7f)
+	data["Sector"] = float32(uint8(session.player["SectorFlags"].Int) & 0x07)
+	data["RaceState"] = float32(uint8(session.player["RaceStateFlags"].Int) & 0x07)
+	data["LapInvalidated"] = float32(uint8(session.player["RaceStateFlags"].Int) >> 7)
+
+	decoded.Normalized = normalize(data)
+
+	published := decoded.GameData(pc.Keys, packet.Source)
+	published.Normalized = &decoded.Normalized
+	pc.Bus.Publish(published)
+}
+
+// normalize maps the merged player values to the normalized sample
+func normalize(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn:           values["IsRaceOn"] != 0,
+		IsPlayer:           true,
+		Speed:              values["Speed"],
+		RPM:                values["Rpm"],
+		MaxRPM:             values["MaxRpm"],
+		Gear:               int(values["Gear"]),
+		Throttle:           values["Throttle"] / 255,
+		Brake:              values["Brake"] / 255,
+		Clutch:             values["Clutch"] / 255,
+		HandBrake:          values["HandBrake"] / 255,
+		Steer:              values["Steering"] / 127,
+		Fuel:               values["FuelLevel"],
+		Position:           telemetry.VectorOf(values, "FullPosition"),
+		Velocity:           telemetry.VectorOf(values, "WorldVelocity"),
+		Acceleration:       telemetry.VectorOf(values, "LocalAcceleration"),
+		Yaw:                values["OrientationY"],
+		Pitch:              values["OrientationX"],
+		Roll:               values["OrientationZ"],
+		WheelSpeed:         telemetry.WheelsOf(values, "TyreRPS").Scale(2 * math.Pi),
+		TireTemperature:    telemetry.WheelsOf(values, "TyreTemp"),
+		HasTireTemperature: true,
+		SuspensionTravel:   telemetry.WheelsOf(values, "SuspensionTravel"),
+		LapNumber:          max(int(values["CurrentLap"])-1, 0),
+		RacePosition:       int(values["RacePosition"]),
+		CurrentLap:         max(values["CurrentTime"], 0),
+		LastLap:            max(values["LastLapTime"], 0),
+		BestLap:            max(values["FastestLapTime"], 0),
+	}
+}
+
+// FormatsFS returns the Project CARS 2 format files, TMD_PCARS2_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
@@ -0,0 +1,180 @@
+package pcars2_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/pcars2"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const playerIndex = 18
+
+func TestParseHeader(t *testing.T) {
+	header, err := pcars2.ParseHeader(packet(pcars2.PacketTimings, 42, 2, 3, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, pcars2.Header{
+		PacketNumber:         1000,
+		CategoryPacketNumber: 42,
+		PartialPacketIndex:   2,
+		PartialPacketNumber:  3,
+		PacketType:           pcars2.PacketTimings,
+		PacketVersion:        1,
+	}, header)
+
+	_, err = pcars2.ParseHeader(make([]byte, 5))
+	assert.ErrorIs(t, err, pcars2.ErrPacketTooShort)
+}
+
+func TestReassembler(t *testing.T) {
+	add := func(r *pcars2.Reassembler, buffer []byte) [][]byte {
+		header, err := pcars2.ParseHeader(buffer)
+		require.NoError(t, err)
+		return r.Add(header, buffer)
+	}
+
+	t.Run("should return single packets", func(t *testing.T) {
+		r := pcars2.NewReassembler()
+		first := packet(pcars2.PacketCarPhysics, 10, 1, 1, 0)
+
+		assert.Equal(t, [][]byte{first}, add(r, first))
+	})
+
+	t.Run("should return the parts ordered when the packet is complete", func(t *testing.T) {
+		r := pcars2.NewReassembler()
+		first := packet(pcars2.PacketParticipants, 10, 1, 2, 0)
+		second := packet(pcars2.PacketParticipants, 10, 2, 2, 0)
+
+		assert.Nil(t, add(r, second))
+		assert.Equal(t, [][]byte{first, second}, add(r, first))
+		assert.Nil(t, add(r, second), "duplicated part")
+	})
+
+	t.Run("should drop the incomplete packet when a newer one arrives", func(t *testing.T) {
+		r := pcars2.NewReassembler()
+		first := packet(pcars2.PacketParticipants, 11, 1, 2, 0)
+		second := packet(pcars2.PacketParticipants, 11, 2, 2, 0)
+
+		assert.Nil(t, add(r, packet(pcars2.PacketParticipants, 10, 1, 2, 0)))
+		assert.Nil(t, add(r, first))
+		assert.Nil(t, add(r, packet(pcars2.PacketParticipants, 10, 2, 2, 0)), "stale part")
+		assert.Equal(t, [][]byte{first, second}, add(r, second))
+	})
+
+	t.Run("should drop the stale packets and accept the packets after the game restart", func(t *testing.T) {
+		r := pcars2.NewReassembler()
+
+		assert.NotNil(t, add(r, packet(pcars2.PacketCarPhysics, 500, 1, 1, 0)))
+		assert.Nil(t, add(r, packet(pcars2.PacketCarPhysics, 499, 1, 1, 0)))
+		assert.NotNil(t, add(r, packet(pcars2.PacketCarPhysics, 1, 1, 1, 0)))
+		assert.NotNil(t, add(r, packet(pcars2.PacketTimings, 1, 1, 1, 0)), "packet types are counted separately")
+	})
+}
+
+func TestPCars2Handler_MergesPlayerData(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	handler := &pcars2.PCars2Handler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: []telemetry.ConverterInterface{adapter},
+		},
+	}
+	require.NoError(t, handler.LoadFormats())
+	channel := make(chan server.Packet)
+	go handler.ProcessChannel(context.Background(), channel, 5606)
+
+	gameState := packet(pcars2.PacketGameState, 1, 1, 1, 24)
+	gameState[14] = 2<<4 | pcars2.GameStatePlaying // GameSessionState
+	gameState[16] = 31                             // TrackTemperature
+	channel <- server.Packet{Data: gameState}
+
+	// 20 participants are sent in two packets
+	names := make([][]byte, 2)
+	for part := range names {
+		names[part] = packet(pcars2.PacketParticipants, 1, uint8(part+1), 2, 1136)
+	}
+	copy(names[1][16+(playerIndex-16)*pcars2.NameLength:], "Player")
+	channel <- server.Packet{Data: names[1]}
+	channel <- server.Packet{Data: names[0]}
+
+	timings := packet(pcars2.PacketTimings, 1, 1, 1, 1063)
+	binary.LittleEndian.PutUint16(timings[1057:], playerIndex)
+	timings[participantOffset(33, playerIndex)+14] = 0x80 | 3 // RacePositionFlags
+	timings[participantOffset(33, playerIndex)+20] = 0x80 | 2 // RaceStateFlags
+	timings[participantOffset(33, playerIndex)+21] = 5        // CurrentLap
+	timings[participantOffset(33, 0)+21] = 9                  // CurrentLap of another participant
+	channel <- server.Packet{Data: timings}
+
+	timeStats := packet(pcars2.PacketTimeStats, 1, 1, 1, 1040)
+	putFloat(timeStats, participantOffset(16, playerIndex)+4, 98.25) // LastLapTime
+	channel <- server.Packet{Data: timeStats}
+
+	carPhysics := packet(pcars2.PacketCarPhysics, 1, 1, 1, 559)
+	putFloat(carPhysics, 36, 55.5)                               // Speed
+	binary.LittleEndian.PutUint16(carPhysics[40:], uint16(7400)) // Rpm
+	carPhysics[45] = 6<<4 | 4                                    // GearNumGears
+	channel <- server.Packet{Data: carPhysics}
+
+	// a late car physics packet, dropped
+	channel <- server.Packet{Data: packet(pcars2.PacketCarPhysics, 0, 1, 1, 559)}
+
+	assert.Eventually(t, func() bool { return adapter.Count() == 1 }, time.Second, 10*time.Millisecond)
+	data := adapter.All()[0]
+
+	assert.Equal(t, handler.Keys, data.Keys)
+	assert.Equal(t, float32(1), data.Data["IsRaceOn"])
+	assert.Equal(t, float32(2), data.Data["SessionState"])
+	assert.Equal(t, float32(31), data.Data["TrackTemperature"])
+	assert.Equal(t, float32(playerIndex), data.Data["PlayerParticipantIndex"])
+	assert.Equal(t, float32(3), data.Data["RacePosition"])
+	assert.Equal(t, float32(2), data.Data["RaceState"])
+	assert.Equal(t, float32(1), data.Data["LapInvalidated"])
+	assert.Equal(t, float32(5), data.Data["CurrentLap"])
+	assert.Equal(t, float32(98.25), data.Data["LastLapTime"])
+	assert.Equal(t, float32(55.5), data.Data["Speed"])
+	assert.Equal(t, float32(7400), data.Data["Rpm"])
+	assert.Equal(t, float32(4), data.Data["Gear"])
+	assert.Equal(t, float32(6), data.Data["NumGears"])
+	assert.Equal(t, telemetry.Value{DataType: "U16", Int: 7400}, data.Values["Rpm"])
+	assert.Equal(t, telemetry.Value{DataType: "S8", Int: 31}, data.Values["TrackTemperature"])
+	assert.NotContains(t, data.Values, "NumGears", "the calculated channels are only in Data")
+
+	require.NotNil(t, data.Normalized)
+	assert.Equal(t, float32(55.5), data.Normalized.Speed)
+	assert.Equal(t, 4, data.Normalized.Gear)
+	assert.Equal(t, 4, data.Normalized.LapNumber)
+	assert.Equal(t, 3, data.Normalized.RacePosition)
+	assert.Equal(t, float32(98.25), data.Normalized.LastLap)
+	assert.Equal(t, "Player", handler.Session(netip.AddrPort{}).Names[playerIndex])
+}
+
+// packet creates the packet with the header, the size includes the header
+func packet(packetType uint8, categoryPacketNumber uint32, partialIndex, partialNumber uint8, size int) []byte {
+	if size < pcars2.HeaderSize {
+		size = pcars2.HeaderSize
+	}
+	buffer := make([]byte, size)
+	binary.LittleEndian.PutUint32(buffer[0:], 1000)
+	binary.LittleEndian.PutUint32(buffer[4:], categoryPacketNumber)
+	buffer[8] = partialIndex
+	buffer[9] = partialNumber
+	buffer[10] = packetType
+	buffer[11] = 1
+	return buffer
+}
+
+func participantOffset(offset, index int) int {
+	return offset + index*32
+}
+
+func putFloat(buffer []byte, offset int, value float32) {
+	binary.LittleEndian.PutUint32(buffer[offset:], math.Float32bits(value))
+}
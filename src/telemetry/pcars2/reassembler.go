@@ -0,0 +1,69 @@
+package pcars2
+
+// staleWindow is the number of packets, the packet older by more packets means the game has been restarted
+const staleWindow = 64
+
+// fragments are the received parts of the last packet of a single packet type
+type fragments struct {
+	categoryPacketNumber uint32
+	parts                [][]byte
+	received             int
+	complete             bool
+}
+
+// Reassembler collects the parts of the packets sent in several UDP packets, eg. the participants of
+// a session with more than 16 cars, and drops the packets older than the last received packet of the same type.
+type Reassembler struct {
+	packets map[uint8]*fragments
+}
+
+// NewReassembler creates a new Reassembler
+func NewReassembler() *Reassembler {
+	return &Reassembler{
+		packets: map[uint8]*fragments{},
+	}
+}
+
+// Add adds the packet and returns all parts of the packet, ordered by the partial packet index,
+// when the last missing part has been received. It returns nil while the packet is not complete
+// and for the stale and duplicated packets.
+func (r *Reassembler) Add(header Header, buffer []byte) [][]byte {
+	count := int(header.PartialPacketNumber)
+	if count < 1 {
+		count = 1
+	}
+	index := int(header.PartialPacketIndex) - 1
+	if count == 1 {
+		index = 0
+	}
+	if index < 0 || index >= count {
+		return nil
+	}
+
+	packet, ok := r.packets[header.PacketType]
+	if ok {
+		age := packet.categoryPacketNumber - header.CategoryPacketNumber
+		if age > 0 && age <= staleWindow {
+			// the UDP packets arrived out of order
+			return nil
+		}
+	}
+	if !ok || header.CategoryPacketNumber != packet.categoryPacketNumber || len(packet.parts) != count {
+		packet = &fragments{
+			categoryPacketNumber: header.CategoryPacketNumber,
+			parts:                make([][]byte, count),
+		}
+		r.packets[header.PacketType] = packet
+	}
+	if packet.complete || packet.parts[index] != nil {
+		return nil
+	}
+
+	packet.parts[index] = buffer
+	packet.received++
+	if packet.received < count {
+		return nil
+	}
+	packet.complete = true
+	return packet.parts
+}
@@ -0,0 +1,327 @@
+package rally
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/converter"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/enums"
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DirtRally2FormatFile describes the DiRT Rally 2.0 "extradata=3" packet
+	DirtRally2FormatFile = "dirtrally2"
+	// WRCStructureFile is the EA WRC packet structure, it has to be copied to Documents/My Games/WRC/telemetry/udp
+	WRCStructureFile = "tmd.json"
+	// WRCChannelsFile describes the types of the EA WRC channels
+	WRCChannelsFile = "channels.json"
+	// WRCPacket is the EA WRC packet sent while driving
+	WRCPacket = "session_update"
+	// FormatsDirEnvKey is the environment variable with the directory of the rally format files
+	FormatsDirEnvKey = "TMD_RALLY_FORMATS"
+)
+
+var ErrPacketTooShort = errors.WithMessage(telemetry.ErrPacketTooShort, "[Rally]")
+
+//go:embed dirtrally2 tmd.json channels.json
+var formatFiles embed.FS
+
+// stageKeys are the channels published for every rally game. A stage is recorded as a lap: LastLap is set
+// to the stage time once when the stage is finished, LapNumber counts the finished stages.
+var stageKeys = []string{
+	"IsRaceOn", "StageTime", "StageDistance", "StageLength", "StageProgress", "Split1Time", "Split2Time",
+	"LastLap", "LapNumber", "TrackOrdinal", "CarOrdinal", "CarClass",
+}
+
+// StageChannels maps the game channels to the stage channels, empty channels are not sent by the game
+type StageChannels struct {
+	Time     string
+	Distance string
+	Length   string
+	Splits   []string
+	// Finished is positive when the stage is finished, Result is the final stage time
+	Finished string
+	Result   string
+	Track    string
+	Car      string
+	CarClass string
+}
+
+// DirtRally2Channels are the stage channels of DiRT Rally 2.0. The game does not send the stage and car IDs,
+// the stage length is used as the track ordinal instead.
+var DirtRally2Channels = StageChannels{
+	Time:     "StageTime",
+	Distance: "StageDistance",
+	Length:   "StageLength",
+	Splits:   []string{"Sector1Time", "Sector2Time"},
+	Finished: "CompletedLaps",
+	Result:   "LastLapTime",
+}
+
+// WRCChannels are the stage channels of EA WRC
+var WRCChannels = StageChannels{
+	Time:     "StageCurrentTime",
+	Distance: "StageCurrentDistance",
+	Length:   "StageLength",
+	Splits:   []string{"StagePreviousSplitTime"},
+	Finished: "StageResultTime",
+	Result:   "StageResultTime",
+	Track:    "RouteId",
+	Car:      "VehicleId",
+	CarClass: "VehicleClassId",
+}
+
+// RallyHandler decodes the rally games telemetry and tracks the stage progress
+type RallyHandler struct {
+	telemetry.TelemetryHandler
+	Game      enums.Game
+	DebugMode string
+	Channels  StageChannels
+	// Keys contains every channel published to the adapters
+	Keys       []string
+	loadSchema func() (*telemetry.Schema, error)
+	normalize  func(values map[string]float32) telemetry.Normalized
+	schema     *telemetry.Schema
+	progress   *telemetry.Sessions[*stageProgress]
+	// samples contains the published samples released by the adapters, reused for the next packets
+	samples telemetry.SamplePool
+}
+
+// stageProgress is the progress of a game sending to the port, stages is the number of finished stages
+type stageProgress struct {
+	finished bool
+	stages   int
+	splits   [2]float32
+}
+
+// NewDirtRally2Handler creates a new RallyHandler for DiRT Rally 2.0
+func NewDirtRally2Handler(debugMode string) *RallyHandler {
+	return &RallyHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.DirtRally2()),
+		},
+		Game:      enums.Games.DirtRally2(),
+		DebugMode: debugMode,
+		Channels:  DirtRally2Channels,
+		loadSchema: func() (*telemetry.Schema, error) {
+			return telemetry.LoadSchema(FormatsFS(), DirtRally2FormatFile)
+		},
+		normalize: normalizeDirtRally2,
+	}
+}
+
+// NewWRCHandler creates a new RallyHandler for EA WRC
+func NewWRCHandler(debugMode string) *RallyHandler {
+	return &RallyHandler{
+		TelemetryHandler: telemetry.TelemetryHandler{
+			Adapters: converter.SetupAdapter(enums.Games.EAWRC()),
+		},
+		Game:      enums.Games.EAWRC(),
+		DebugMode: debugMode,
+		Channels:  WRCChannels,
+		loadSchema: func() (*telemetry.Schema, error) {
+			return LoadWRCSchema(FormatsFS(), WRCStructureFile, WRCChannelsFile, WRCPacket)
+		},
+		normalize: normalizeWRC,
+	}
+}
+
+// InitAndRun starts the RallyHandler
+func (r *RallyHandler) InitAndRun(ctx context.Context, port int) error {
+	err := r.LoadFormats()
+	if err != nil {
+		return err
+	}
+
+	udpServer := server.NewServer("0.0.0.0:" + strconv.Itoa(port))
+
+	log.Printf(
+		"[%s] UDP server listening on %s:%d, waiting for rally data...\n", r.Game, telemetry.GetOutboundIP(), port,
+	)
+
+	return udpServer.Run(ctx, r.ProcessChannel, port)
+}
+
+// LoadFormats loads the packet format and builds the list of published channels
+func (r *RallyHandler) LoadFormats() error {
+	schema, err := r.loadSchema()
+	if err != nil {
+		return err
+	}
+	r.schema = schema
+
+	r.Keys = telemetry.MergeKeys(stageKeys, schema.Keys)
+	r.progress = telemetry.NewSessions(func() *stageProgress { return &stageProgress{} })
+
+	return nil
+}
+
+func (r *RallyHandler) ProcessChannel(_ context.Context, channel chan server.Packet, port int) {
+	r.ProcessPackets(channel, port, r.ProcessBuffer)
+}
+
+// ProcessBuffer decodes the packet, adds the stage channels and publishes the data
+func (r *RallyHandler) ProcessBuffer(packet server.Packet, _ int) error {
+	buffer := packet.Data
+	if len(buffer) < r.schema.Size {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d bytes", r.schema.Name, len(buffer))
+	}
+
+	decoded := r.samples.Get()
+	decoded.Buffer = buffer
+	r.schema.DecodeValuesInto(buffer[:r.schema.Size], decoded.Values)
+	values := decoded.Data
+	telemetry.FloatsInto(decoded.Values, values)
+	r.addStageValues(r.progress.Get(packet.Source), values)
+	decoded.Normalized = r.normalize(values)
+
+	published := decoded.GameData(r.Keys, packet.Source)
+	published.Normalized = &decoded.Normalized
+	r.Bus.Publish(published)
+	return nil
+}
+
+// addStageValues calculates the stage channels, LastLap is set only in the packet which finishes the stage
+func (r *RallyHandler) addStageValues(progress *stageProgress, values map[string]float32) {
+	stageTime := values[r.Channels.Time]
+	stageLength := values[r.Channels.Length]
+	finished := values[r.Channels.Finished] > 0
+
+	if stageTime == 0 && !finished {
+		// the stage has been restarted
+		progress.splits = [2]float32{}
+	}
+	if len(r.Channels.Splits) == 1 {
+		// the game sends only the last split time
+		split := values[r.Channels.Splits[0]]
+		if split > 0 && progress.splits[0] == 0 {
+			progress.splits[0] = split
+		} else if split > 0 && split != progress.splits[0] {
+			progress.splits[1] = split
+		}
+	} else {
+		for i, channel := range r.Channels.Splits {
+			if i < len(progress.splits) {
+				progress.splits[i] = values[channel]
+			}
+		}
+	}
+
+	values["IsRaceOn"] = 0
+	if stageTime > 0 && !finished {
+		values["IsRaceOn"] = 1
+	}
+	values["StageTime"] = stageTime
+	values["StageDistance"] = values[r.Channels.Distance]
+	values["StageLength"] = stageLength
+	values["StageProgress"] = 0
+	if stageLength > 0 {
+		values["StageProgress"] = float32(math.Min(math.Max(float64(values["StageDistance"]/stageLength), 0), 1))
+	}
+	values["Split1Time"] = progress.splits[0]
+	values["Split2Time"] = progress.splits[1]
+
+	values["LastLap"] = 0
+	if finished && !progress.finished {
+		progress.stages++
+		values["LastLap"] = values[r.Channels.Result]
+		log.Printf("[%s] Stage finished in %.3fs\n", r.Game, values["LastLap"])
+	}
+	progress.finished = finished
+	values["LapNumber"] = float32(progress.stages)
+
+	values["TrackOrdinal"] = float32(math.Round(float64(stageLength)))
+	if r.Channels.Track != "" {
+		values["TrackOrdinal"] = values[r.Channels.Track]
+	}
+	values["CarOrdinal"] = channelValue(values, r.Channels.Car)
+	values["CarClass"] = channelValue(values, r.Channels.CarClass)
+}
+
+// normalizeStage maps the stage channels to the normalized sample, the stage time is the current lap time
+func normalizeStage(values map[string]float32) telemetry.Normalized {
+	return telemetry.Normalized{
+		IsRaceOn:   values["IsRaceOn"] != 0,
+		IsPlayer:   true,
+		LapNumber:  int(values["LapNumber"]),
+		CurrentLap: values["StageTime"],
+		LastLap:    values["LastLap"],
+		Distance:   values["StageDistance"],
+		CarID:      int(values["CarOrdinal"]),
+		CarClass:   int(values["CarClass"]),
+		TrackID:    int(values["TrackOrdinal"]),
+	}
+}
+
+// normalizeDirtRally2 maps the DiRT Rally 2.0 values to the normalized sample.
+// The game sends the reverse as the gear 10, the RPM divided by 10 and the suspension position in millimetres.
+func normalizeDirtRally2(values map[string]float32) telemetry.Normalized {
+	normalized := normalizeStage(values)
+	normalized.Speed = values["Speed"]
+	normalized.RPM = values["EngineRate"] * 10
+	normalized.MaxRPM = values["MaxEngineRate"] * 10
+	normalized.IdleRPM = values["IdleEngineRate"] * 10
+	normalized.Gear = int(values["Gear"])
+	if normalized.Gear == 10 {
+		normalized.Gear = -1
+	}
+	normalized.Throttle = values["Throttle"]
+	normalized.Brake = values["Brake"]
+	normalized.Clutch = values["Clutch"]
+	normalized.Steer = values["Steering"]
+	normalized.Fuel = telemetry.Ratio(values["FuelInTank"], values["FuelCapacity"])
+	normalized.Position = telemetry.VectorOf(values, "Position")
+	normalized.Velocity = telemetry.VectorOf(values, "Velocity")
+	normalized.Acceleration = telemetry.Vector3{
+		X: values["GForceLateral"] * telemetry.StandardGravity,
+		Z: values["GForceLongitudinal"] * telemetry.StandardGravity,
+	}
+	normalized.SuspensionTravel = telemetry.WheelsOf(values, "SuspensionPosition").Scale(0.001)
+	normalized.RacePosition = int(values["RacePosition"])
+	return normalized
+}
+
+// normalizeWRC maps the EA WRC values to the normalized sample, the game sends the neutral and reverse gear indexes
+func normalizeWRC(values map[string]float32) telemetry.Normalized {
+	normalized := normalizeStage(values)
+	normalized.Speed = values["VehicleSpeed"]
+	normalized.RPM = values["VehicleEngineRpmCurrent"]
+	normalized.MaxRPM = values["VehicleEngineRpmMax"]
+	normalized.IdleRPM = values["VehicleEngineRpmIdle"]
+	switch gear := values["VehicleGearIndex"]; gear {
+	case values["VehicleGearIndexReverse"]:
+		normalized.Gear = -1
+	case values["VehicleGearIndexNeutral"]:
+		normalized.Gear = 0
+	default:
+		normalized.Gear = int(gear - values["VehicleGearIndexNeutral"])
+	}
+	normalized.Throttle = values["VehicleThrottle"]
+	normalized.Brake = values["VehicleBrake"]
+	normalized.Clutch = values["VehicleClutch"]
+	normalized.HandBrake = values["VehicleHandbrake"]
+	normalized.Steer = values["VehicleSteering"]
+	normalized.Position = telemetry.VectorOf(values, "VehiclePosition")
+	normalized.Velocity = telemetry.VectorOf(values, "VehicleVelocity")
+	normalized.Acceleration = telemetry.VectorOf(values, "VehicleAcceleration")
+	return normalized
+}
+
+func channelValue(values map[string]float32, channel string) float32 {
+	if channel == "" {
+		return 0
+	}
+	return values[channel]
+}
+
+// FormatsFS returns the rally format files, TMD_RALLY_FORMATS overrides them, see telemetry.FormatsFS
+func FormatsFS() fs.FS {
+	return telemetry.FormatsFS(formatFiles, FormatsDirEnvKey)
+}
@@ -0,0 +1,208 @@
+package rally_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry/rally"
+	"github.com/bluemanos/simracing-telemetry/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWRCSchema(t *testing.T) {
+	schema, err := rally.LoadWRCSchema(
+		rally.FormatsFS(), rally.WRCStructureFile, rally.WRCChannelsFile, rally.WRCPacket,
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tmd/session_update", schema.Name)
+	assert.Equal(t, 180, schema.Size)
+	assert.Equal(t, "GameTotalTime", schema.Keys[0])
+	assert.Equal(t, telemetry.TelemetryData{
+		Position: 1, Name: "GameDeltaTime", DataType: "F32", StartOffset: 16, EndOffset: 20,
+	}, schema.Telemetries["GameDeltaTime"])
+	assert.Equal(t, "F64", schema.Telemetries["StageCurrentDistance"].DataType)
+	assert.NotContains(t, schema.Keys, "PacketUid")
+
+	t.Run("errors", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"structure.json": {Data: []byte(`{"id": "custom", "packets": [
+				{"id": "session_update", "channels": ["stage_current_time", "vehicle_name"]}
+			]}`)},
+			"channels.json": {Data: []byte(`{"channels": [
+				{"id": "stage_current_time", "type": "float32"},
+				{"id": "vehicle_name", "type": "string"}
+			]}`)},
+			"unknown.json": {Data: []byte(`{"channels": [{"id": "stage_current_time", "type": "float32"}]}`)},
+		}
+
+		_, err := rally.LoadWRCSchema(fsys, "structure.json", "channels.json", "session_start")
+		assert.ErrorIs(t, err, rally.ErrUnknownPacket)
+
+		_, err = rally.LoadWRCSchema(fsys, "structure.json", "channels.json", "session_update")
+		assert.ErrorIs(t, err, rally.ErrUnknownChannelType)
+
+		_, err = rally.LoadWRCSchema(fsys, "structure.json", "unknown.json", "session_update")
+		assert.ErrorIs(t, err, rally.ErrUnknownChannel)
+	})
+}
+
+func TestRallyHandler_DirtRally2Stage(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	handler := rally.NewDirtRally2Handler("")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+	require.NoError(t, handler.LoadFormats())
+	channel := make(chan server.Packet)
+	go handler.ProcessChannel(context.Background(), channel, 20777)
+
+	finished := map[int]float32{1: 301.5, 2: 10000, 49: 60.25, 50: 70.5, 59: 1, 61: 10000, 62: 301.5}
+	for _, values := range []map[int]float32{
+		// on the stage, after the first split
+		{1: 80.5, 2: 2500, 49: 60.25, 61: 10000},
+		// the stage is finished, the game keeps sending the finished stage
+		finished,
+		finished,
+		// the next stage
+		{1: 0, 2: -10, 61: 7500},
+	} {
+		channel <- server.Packet{Data: dirtRally2Packet(values)}
+	}
+
+	require.Eventually(t, func() bool { return adapter.Count() == 4 }, time.Second, 10*time.Millisecond)
+	data := adapter.All()
+
+	assert.Equal(t, handler.Keys, data[0].Keys)
+	assert.Equal(t, float32(1), data[0].Data["IsRaceOn"])
+	assert.Equal(t, float32(80.5), data[0].Data["StageTime"])
+	assert.Equal(t, float32(0.25), data[0].Data["StageProgress"])
+	assert.Equal(t, float32(60.25), data[0].Data["Split1Time"])
+	assert.Equal(t, float32(0), data[0].Data["LastLap"])
+	assert.Equal(t, float32(10000), data[0].Data["TrackOrdinal"])
+
+	assert.Equal(t, float32(0), data[1].Data["IsRaceOn"])
+	assert.Equal(t, float32(301.5), data[1].Data["LastLap"])
+	assert.Equal(t, float32(1), data[1].Data["LapNumber"])
+	assert.Equal(t, float32(70.5), data[1].Data["Split2Time"])
+	assert.Equal(t, float32(0), data[2].Data["LastLap"], "the stage time is recorded once")
+
+	assert.Equal(t, float32(0), data[3].Data["IsRaceOn"])
+	assert.Equal(t, float32(0), data[3].Data["StageProgress"])
+	assert.Equal(t, float32(1), data[3].Data["LapNumber"])
+	assert.Equal(t, float32(7500), data[3].Data["TrackOrdinal"])
+}
+
+func TestRallyHandler_SeparatesSources(t *testing.T) {
+	adapter := &test.RecordingAdapter{}
+	handler := rally.NewDirtRally2Handler("")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+	require.NoError(t, handler.LoadFormats())
+	channel := make(chan server.Packet)
+	go handler.ProcessChannel(context.Background(), channel, 20777)
+
+	first := netip.MustParseAddrPort("192.168.1.10:50001")
+	second := netip.MustParseAddrPort("192.168.1.11:50001")
+	finished := dirtRally2Packet(map[int]float32{1: 301.5, 2: 10000, 59: 1, 61: 10000, 62: 301.5})
+	// two consoles send to the same port, only the first one finishes the stage
+	channel <- server.Packet{Data: dirtRally2Packet(map[int]float32{1: 290, 2: 9500, 61: 10000}), Source: first}
+	channel <- server.Packet{Data: dirtRally2Packet(map[int]float32{1: 120, 2: 4000, 61: 10000}), Source: second}
+	channel <- server.Packet{Data: finished, Source: first}
+	channel <- server.Packet{Data: dirtRally2Packet(map[int]float32{1: 121, 2: 4050, 61: 10000}), Source: second}
+	channel <- server.Packet{Data: finished, Source: first}
+
+	require.Eventually(t, func() bool { return adapter.Count() == 5 }, time.Second, 10*time.Millisecond)
+	data := adapter.All()
+
+	assert.Equal(t, first, data[2].Source)
+	assert.Equal(t, float32(301.5), data[2].Data["LastLap"])
+	assert.Equal(t, float32(1), data[2].Data["LapNumber"])
+
+	assert.Equal(t, second, data[3].Source)
+	assert.Equal(t, float32(1), data[3].Data["IsRaceOn"])
+	assert.Equal(t, float32(0), data[3].Data["LapNumber"])
+
+	assert.Equal(t, float32(0), data[4].Data["LastLap"], "the stage time is recorded once")
+	assert.Equal(t, float32(1), data[4].Data["LapNumber"])
+}
+
+func TestRallyHandler_WRCStage(t *testing.T) {
+	schema, err := rally.LoadWRCSchema(
+		rally.FormatsFS(), rally.WRCStructureFile, rally.WRCChannelsFile, rally.WRCPacket,
+	)
+	require.NoError(t, err)
+	packet := func(values map[string]float64) []byte {
+		buffer := make([]byte, schema.Size)
+		for name, value := range values {
+			field := schema.Telemetries[name]
+			switch field.DataType {
+			case "F32":
+				binary.LittleEndian.PutUint32(buffer[field.StartOffset:], math.Float32bits(float32(value)))
+			case "F64":
+				binary.LittleEndian.PutUint64(buffer[field.StartOffset:], math.Float64bits(value))
+			case "U16":
+				binary.LittleEndian.PutUint16(buffer[field.StartOffset:], uint16(value))
+			}
+		}
+		return buffer
+	}
+
+	adapter := &test.RecordingAdapter{}
+	handler := rally.NewWRCHandler("")
+	handler.Adapters = []telemetry.ConverterInterface{adapter}
+	require.NoError(t, handler.LoadFormats())
+	channel := make(chan server.Packet)
+	go handler.ProcessChannel(context.Background(), channel, 20778)
+
+	stage := map[string]float64{"RouteId": 412, "VehicleId": 77, "VehicleClassId": 5, "StageLength": 8000}
+	for _, values := range []map[string]float64{
+		{"StageCurrentTime": 50, "StageCurrentDistance": 2000, "StagePreviousSplitTime": 0},
+		{"StageCurrentTime": 120, "StageCurrentDistance": 4000, "StagePreviousSplitTime": 110.5},
+		{"StageCurrentTime": 220, "StageCurrentDistance": 6000, "StagePreviousSplitTime": 200.25},
+		{
+			"StageCurrentTime": 290, "StageCurrentDistance": 8000, "StagePreviousSplitTime": 200.25,
+			"StageResultTime": 290.75,
+		},
+	} {
+		for name, value := range stage {
+			values[name] = value
+		}
+		channel <- server.Packet{Data: packet(values)}
+	}
+
+	require.Eventually(t, func() bool { return adapter.Count() == 4 }, time.Second, 10*time.Millisecond)
+	data := adapter.All()
+
+	assert.Equal(t, float32(1), data[0].Data["IsRaceOn"])
+	assert.Equal(t, float32(0.25), data[0].Data["StageProgress"])
+	assert.Equal(t, float32(110.5), data[1].Data["Split1Time"])
+	assert.Equal(t, float32(200.25), data[2].Data["Split2Time"])
+
+	finished := data[3].Data
+	assert.Equal(t, float32(0), finished["IsRaceOn"])
+	assert.Equal(t, float32(290.75), finished["LastLap"])
+	assert.Equal(t, float32(1), finished["LapNumber"])
+	assert.Equal(t, float32(412), finished["TrackOrdinal"])
+	assert.Equal(t, float32(77), finished["CarOrdinal"])
+	assert.Equal(t, float32(5), finished["CarClass"])
+
+	require.NotNil(t, data[3].Normalized)
+	assert.Equal(t, float32(290.75), data[3].Normalized.LastLap)
+	assert.Equal(t, 1, data[3].Normalized.LapNumber)
+	assert.Equal(t, 412, data[3].Normalized.TrackID)
+}
+
+// dirtRally2Packet creates the packet of 66 floats with the values at the given indexes
+func dirtRally2Packet(values map[int]float32) []byte {
+	buffer := make([]byte, 66*4)
+	for index, value := range values {
+		binary.LittleEndian.PutUint32(buffer[index*4:], math.Float32bits(value))
+	}
+	return buffer
+}
@@ -0,0 +1,125 @@
+package rally
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownPacket      = errors.New("[WRC] packet not found in the structure")
+	ErrUnknownChannel     = errors.New("[WRC] channel not found in the channels definition")
+	ErrUnknownChannelType = errors.New("[WRC] unsupported channel type")
+)
+
+// channelTypes maps the EA WRC channel types to the format data types.
+// The types which cannot be stored as a float are skipped as the padding.
+var channelTypes = map[string]string{
+	"boolean": "U8",
+	"uint8":   "U8",
+	"int8":    "S8",
+	"uint16":  "U16",
+	"int16":   "S16",
+	"uint32":  "U32",
+	"int32":   "S32",
+	"float32": "F32",
+	"float64": "F64",
+	"uint64":  "PAD 8",
+	"int64":   "PAD 8",
+	"fourcc":  "PAD 4",
+}
+
+// wrcStructure is the packet structure file from the Documents/My Games/WRC/telemetry/udp directory
+type wrcStructure struct {
+	ID      string `json:"id"`
+	Packets []struct {
+		ID     string `json:"id"`
+		Header struct {
+			Channels []string `json:"channels"`
+		} `json:"header"`
+		Channels []string `json:"channels"`
+	} `json:"packets"`
+}
+
+// wrcChannels is the channels definition file from the Documents/My Games/WRC/telemetry/readme directory
+type wrcChannels struct {
+	Channels []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"channels"`
+}
+
+// LoadWRCSchema builds the packet format from the EA WRC packet structure and channels definition files.
+// The channel IDs are converted to the field names, eg. stage_current_time to StageCurrentTime.
+func LoadWRCSchema(fsys fs.FS, structureFile, channelsFile, packetID string) (*telemetry.Schema, error) {
+	var structure wrcStructure
+	err := readJSON(fsys, structureFile, &structure)
+	if err != nil {
+		return nil, err
+	}
+	var channels wrcChannels
+	err = readJSON(fsys, channelsFile, &channels)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string, len(channels.Channels))
+	for _, channel := range channels.Channels {
+		types[channel.ID] = channel.Type
+	}
+
+	for _, packet := range structure.Packets {
+		if packet.ID != packetID {
+			continue
+		}
+
+		var format strings.Builder
+		for _, channel := range append(append([]string{}, packet.Header.Channels...), packet.Channels...) {
+			channelType, ok := types[channel]
+			if !ok {
+				return nil, errors.Wrapf(ErrUnknownChannel, "%s", channel)
+			}
+			dataType, ok := channelTypes[channelType]
+			if !ok {
+				return nil, errors.Wrapf(ErrUnknownChannelType, "%s: %s", channel, channelType)
+			}
+			if strings.HasPrefix(dataType, telemetry.PaddingType) {
+				fmt.Fprintf(&format, "# %s\n%s\n", channel, dataType)
+				continue
+			}
+			fmt.Fprintf(&format, "%s %s\n", dataType, fieldName(channel))
+		}
+
+		schema, err := telemetry.ParseSchema(strings.NewReader(format.String()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "structure %s, packet %s", structure.ID, packetID)
+		}
+		schema.Name = structure.ID + "/" + packetID
+		return schema, nil
+	}
+
+	return nil, errors.Wrapf(ErrUnknownPacket, "structure %s, packet %s", structure.ID, packetID)
+}
+
+func readJSON(fsys fs.FS, name string, value any) error {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(json.Unmarshal(content, value), "file %s", name)
+}
+
+// fieldName converts the channel ID to the field name
+func fieldName(channel string) string {
+	words := strings.Split(channel, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
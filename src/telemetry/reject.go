@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/pkg/errors"
+)
+
+// Reasons of the rejected packets
+const (
+	RejectTooShort      = "too_short"
+	RejectUnknownFormat = "unknown_format"
+	RejectInvalid       = "invalid"
+	RejectPanic         = "panic"
+)
+
+var (
+	// ErrPacketTooShort is wrapped by the game errors of the packets shorter than their layout
+	ErrPacketTooShort = errors.New("packet too short")
+	// ErrUnknownFormat is wrapped by the game errors of the packets not matching any supported layout
+	ErrUnknownFormat = errors.New("unknown packet format")
+	// ErrDecoderPanic is returned when the decoder panics on a malformed packet
+	ErrDecoderPanic = errors.New("decoder panic")
+)
+
+// RejectCounter counts the packets rejected by a game decoder by the reason
+type RejectCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// Add counts the rejected packet and returns the number of packets rejected for the reason so far
+func (r *RejectCounter) Add(reason string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.counts == nil {
+		r.counts = map[string]uint64{}
+	}
+	r.counts[reason]++
+	return r.counts[reason]
+}
+
+// Counts returns the number of rejected packets by the reason
+func (r *RejectCounter) Counts() map[string]uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]uint64, len(r.counts))
+	for reason, count := range r.counts {
+		counts[reason] = count
+	}
+	return counts
+}
+
+// RejectReason classifies the error returned by the decoder
+func RejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrPacketTooShort):
+		return RejectTooShort
+	case errors.Is(err, ErrUnknownFormat):
+		return RejectUnknownFormat
+	case errors.Is(err, ErrDecoderPanic):
+		return RejectPanic
+	}
+	return RejectInvalid
+}
+
+// Process runs the decoder on the received packet. The packet is rejected when the decoder returns an error
+// or panics, eg. on a stray packet of a port scan or another game, so a single packet never stops the ingestion.
+// The rejected packets are counted by the reason and dumped in the vvv debug mode, the counters are exported
+// by the metrics adapters, see Metrics.
+func (t *TelemetryHandler) Process(packet server.Packet, port int, decoder func(packet server.Packet, port int) error) {
+	t.registered.Do(func() { registerHandler(port, t) })
+	t.Received.Add(1)
+	err := decode(packet, port, decoder)
+	if err == nil {
+		return
+	}
+
+	reason := RejectReason(err)
+	if count := t.Rejects.Add(reason); count%DefaultQueueSize == 1 {
+		log.Printf(
+			"[%d] packet from %s rejected (%s): %v, %d %s packets rejected so far",
+			port, packet.Source, reason, err, count, reason,
+		)
+	}
+	DisplayLog("vvv", fmt.Sprintf(
+		"Rejected %d bytes packet from %s (%s): %v\n%s",
+		len(packet.Data), packet.Source, reason, err, hex.Dump(packet.Data),
+	))
+}
+
+func decode(packet server.Packet, port int, decoder func(packet server.Packet, port int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(ErrDecoderPanic, "%v", r)
+		}
+	}()
+	return decoder(packet, port)
+}
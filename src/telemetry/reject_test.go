@@ -0,0 +1,41 @@
+package telemetry_test
+
+import (
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelemetryHandler_Process(t *testing.T) {
+	handler := &telemetry.TelemetryHandler{}
+	decoded := 0
+	decoder := func(packet server.Packet, _ int) error {
+		switch len(packet.Data) {
+		case 0:
+			return errors.Wrap(telemetry.ErrPacketTooShort, "empty")
+		case 1:
+			return errors.WithMessage(telemetry.ErrUnknownFormat, "[Test]")
+		case 2:
+			return errors.New("invalid checksum")
+		case 3:
+			_ = packet.Data[10]
+		}
+		decoded++
+		return nil
+	}
+
+	for _, buffer := range [][]byte{{}, {1}, {1}, {1, 2}, {1, 2, 3}, {1, 2, 3, 4}} {
+		assert.NotPanics(t, func() { handler.Process(server.Packet{Data: buffer}, 1234, decoder) })
+	}
+
+	assert.Equal(t, 1, decoded)
+	assert.Equal(t, map[string]uint64{
+		telemetry.RejectTooShort:      1,
+		telemetry.RejectUnknownFormat: 2,
+		telemetry.RejectInvalid:       1,
+		telemetry.RejectPanic:         1,
+	}, handler.Rejects.Counts())
+}
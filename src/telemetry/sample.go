@@ -0,0 +1,57 @@
+package telemetry
+
+import (
+	"net/netip"
+	"sync"
+
+	"github.com/bluemanos/simracing-telemetry/src/pkg/server"
+)
+
+// Sample is a decoded packet published to the adapters. The decoders take the samples from a SamplePool,
+// so the maps and the packet buffer are reused once every adapter has released the published data.
+type Sample struct {
+	Values     map[string]Value
+	Data       map[string]float32
+	Normalized Normalized
+	// Buffer is the packet buffer the sample was decoded from, it is returned to the server pool with the sample
+	Buffer    []byte
+	lifecycle *Lifecycle
+}
+
+// SamplePool contains the samples released by the adapters, the zero value is ready to use
+type SamplePool struct {
+	pool sync.Pool
+}
+
+// Get returns a released sample with the empty maps, or a new one
+func (p *SamplePool) Get() *Sample {
+	if released, ok := p.pool.Get().(*Sample); ok {
+		clear(released.Values)
+		clear(released.Data)
+		released.Normalized = Normalized{}
+		return released
+	}
+	sample := &Sample{Values: map[string]Value{}, Data: map[string]float32{}}
+	sample.lifecycle = NewLifecycle(func() { p.Put(sample) })
+	return sample
+}
+
+// Put returns the sample and its buffer to the pools, the decoders put back the samples they do not publish
+func (p *SamplePool) Put(sample *Sample) {
+	server.ReleaseBuffer(sample.Buffer)
+	sample.Buffer = nil
+	p.pool.Put(sample)
+}
+
+// GameData returns the data of the sample, the sample is put back to its pool once every adapter has released
+// the data. The normalized sample is not set, as the data which is not a telemetry sample does not have it.
+func (s *Sample) GameData(keys []string, source netip.AddrPort) GameData {
+	return GameData{
+		Keys:      keys,
+		Data:      s.Data,
+		Values:    s.Values,
+		RawData:   s.Buffer,
+		Source:    source,
+		lifecycle: s.lifecycle,
+	}
+}
@@ -0,0 +1,263 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidSchemaLine = errors.New("[Schema] invalid format line")
+	ErrUnknownDataType   = errors.New("[Schema] unknown data type")
+	ErrDuplicateField    = errors.New("[Schema] duplicated field name")
+	ErrEmptySchema       = errors.New("[Schema] format has no fields")
+	ErrDuplicateSize     = errors.New("[Schema] formats with the same packet size")
+)
+
+// PaddingType skips the given number of bytes, eg. `PAD 12`
+const PaddingType = "PAD"
+
+// DataTypeSizes contains the size in bytes of every supported data type
+var DataTypeSizes = map[string]int{
+	"S8":  1,
+	"U8":  1,
+	"U16": 2,
+	"S16": 2,
+	"S32": 4,
+	"U32": 4,
+	"F32": 4,
+	"F64": 8,
+}
+
+var fieldNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Schema describes the layout of a single packet format
+type Schema struct {
+	Name        string
+	Telemetries map[string]TelemetryData
+	Keys        []string
+	Size        int
+}
+
+// LoadSchema reads and parses the format file from the file system
+func LoadSchema(fsys fs.FS, name string) (*Schema, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	schema, err := ParseSchema(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "format %s", name)
+	}
+	schema.Name = name
+
+	return schema, nil
+}
+
+// LoadFormats loads every format file and indexes the formats by their packet size
+func LoadFormats(fsys fs.FS, names ...string) (map[int]*Schema, error) {
+	formats := make(map[int]*Schema, len(names))
+	for _, name := range names {
+		schema, err := LoadSchema(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := formats[schema.Size]; exists {
+			return nil, errors.Wrapf(ErrDuplicateSize, "%s and %s: %d bytes", other.Name, name, schema.Size)
+		}
+		formats[schema.Size] = schema
+	}
+
+	return formats, nil
+}
+
+// ParseSchema builds the packet layout from the format description.
+// Every line describes a single field as `TYPE Name`, eg. `F32 EngineMaxRpm`, in the packet order.
+// Offsets are calculated from the data type sizes. Bytes not used by any field are skipped with `PAD <bytes>`.
+// Empty lines and lines starting with `#` are skipped.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	schema := &Schema{
+		Telemetries: map[string]TelemetryData{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == PaddingType {
+			padding, err := strconv.Atoi(fields[1])
+			if err != nil || padding <= 0 {
+				return nil, errors.Wrapf(ErrInvalidSchemaLine, "line %d: %q", lineNumber, line)
+			}
+			schema.Size += padding
+			continue
+		}
+		if len(fields) != 2 || !fieldNameRegexp.MatchString(fields[1]) {
+			return nil, errors.Wrapf(ErrInvalidSchemaLine, "line %d: %q", lineNumber, line)
+		}
+		dataType, name := fields[0], fields[1]
+
+		size, ok := DataTypeSizes[dataType]
+		if !ok {
+			return nil, errors.Wrapf(ErrUnknownDataType, "line %d: %q", lineNumber, dataType)
+		}
+		if _, exists := schema.Telemetries[name]; exists {
+			return nil, errors.Wrapf(ErrDuplicateField, "line %d: %q", lineNumber, name)
+		}
+
+		schema.Telemetries[name] = TelemetryData{
+			Position:    len(schema.Keys),
+			Name:        name,
+			DataType:    dataType,
+			StartOffset: schema.Size,
+			EndOffset:   schema.Size + size,
+		}
+		schema.Keys = append(schema.Keys, name)
+		schema.Size += size
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(schema.Keys) == 0 {
+		return nil, ErrEmptySchema
+	}
+
+	return schema, nil
+}
+
+// Check validates the buffer length against the packet layout
+func (s *Schema) Check(buffer []byte) error {
+	if len(buffer) < s.Size {
+		return errors.Wrapf(ErrPacketTooShort, "%s: %d of %d bytes", s.Name, len(buffer), s.Size)
+	}
+	return nil
+}
+
+// Decode reads every field of the schema from the buffer.
+// Fields past the end of a short buffer are not set, use Check to reject the short packets.
+func (s *Schema) Decode(buffer []byte) map[string]float32 {
+	values := make(map[string]float32, len(s.Telemetries))
+
+	for i, telemetryObj := range s.Telemetries {
+		if telemetryObj.EndOffset > len(buffer) {
+			continue
+		}
+		data := buffer[telemetryObj.StartOffset:telemetryObj.EndOffset]
+		values[i] = DecodeValue(telemetryObj.DataType, data).Float32()
+	}
+
+	return values
+}
+
+// DecodeValues reads every field of the schema from the buffer in its native type.
+// Fields past the end of a short buffer are not set, as in Decode.
+func (s *Schema) DecodeValues(buffer []byte) map[string]Value {
+	values := make(map[string]Value, len(s.Telemetries))
+	s.DecodeValuesInto(buffer, values)
+	return values
+}
+
+// DecodeValuesInto clears the values and reads every field of the schema into them,
+// so the same map is reused for every packet
+func (s *Schema) DecodeValuesInto(buffer []byte, values map[string]Value) {
+	clear(values)
+	s.MergeValues(buffer, values)
+}
+
+// MergeValues reads every field of the schema into the values, keeping the other fields,
+// eg. to merge the packets carrying the parts of the same sample
+func (s *Schema) MergeValues(buffer []byte, values map[string]Value) {
+	for i, telemetryObj := range s.Telemetries {
+		if telemetryObj.EndOffset > len(buffer) {
+			continue
+		}
+		values[i] = DecodeValue(telemetryObj.DataType, buffer[telemetryObj.StartOffset:telemetryObj.EndOffset])
+	}
+}
+
+// Encode builds the packet from the values, missing fields are left zeroed.
+// Integer values are rounded and clamped to the range of the data type.
+func (s *Schema) Encode(values map[string]float32) []byte {
+	native := make(map[string]Value, len(values))
+	for name, value := range values {
+		native[name] = Value{DataType: "F32", Float: float64(value)}
+	}
+	return s.EncodeValues(native)
+}
+
+// EncodeValues builds the packet from the values in their native types, so the integers are written
+// without the float32 rounding. Missing fields are left zeroed, the values are converted to the field
+// data type as in Encode.
+func (s *Schema) EncodeValues(values map[string]Value) []byte {
+	buffer := make([]byte, s.Size)
+
+	for name, telemetryObj := range s.Telemetries {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		data := buffer[telemetryObj.StartOffset:telemetryObj.EndOffset]
+
+		switch telemetryObj.DataType {
+		case "F32":
+			binary.LittleEndian.PutUint32(data, math.Float32bits(value.Float32()))
+		case "F64":
+			binary.LittleEndian.PutUint64(data, math.Float64bits(value.Float64()))
+		case "U8":
+			data[0] = uint8(clamp(value, 0, math.MaxUint8))
+		case "S8":
+			data[0] = uint8(int8(clamp(value, math.MinInt8, math.MaxInt8)))
+		case "U16":
+			binary.LittleEndian.PutUint16(data, uint16(clamp(value, 0, math.MaxUint16)))
+		case "S16":
+			binary.LittleEndian.PutUint16(data, uint16(int16(clamp(value, math.MinInt16, math.MaxInt16))))
+		case "S32":
+			binary.LittleEndian.PutUint32(data, uint32(int32(clamp(value, math.MinInt32, math.MaxInt32))))
+		default:
+			binary.LittleEndian.PutUint32(data, uint32(clamp(value, 0, math.MaxUint32)))
+		}
+	}
+
+	return buffer
+}
+
+// MergeKeys returns the keys of the derived channels and the schemas in their order, the keys found in several
+// schemas are kept once, at their first position
+func MergeKeys(keys ...[]string) []string {
+	merged := make([]string, 0, len(keys[0]))
+	known := map[string]bool{}
+	for _, schemaKeys := range keys {
+		for _, key := range schemaKeys {
+			if known[key] {
+				continue
+			}
+			known[key] = true
+			merged = append(merged, key)
+		}
+	}
+	return merged
+}
+
+// clamp returns the value rounded to the integer in the range
+func clamp(value Value, minimum, maximum int64) int64 {
+	if value.IsFloat() {
+		return int64(math.Min(math.Max(math.Round(value.Float), float64(minimum)), float64(maximum)))
+	}
+	return min(max(value.Int, minimum), maximum)
+}
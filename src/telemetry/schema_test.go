@@ -0,0 +1,158 @@
+package telemetry_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchema(t *testing.T) {
+	tt := []struct {
+		testName      string
+		format        string
+		expectedKeys  []string
+		expectedSize  int
+		expectedError error
+	}{
+		{
+			testName:     "valid format",
+			format:       "S32 IsRaceOn\nU32 TimestampMS\n\n# inputs\nU8 Accel\nS8 Steer\nU16 LapNumber\nF32 Speed\n",
+			expectedKeys: []string{"IsRaceOn", "TimestampMS", "Accel", "Steer", "LapNumber", "Speed"},
+			expectedSize: 16,
+		},
+		{
+			testName:     "padding between fields",
+			format:       "S32 IsRaceOn\nPAD 12\nF32 Speed\nPAD 1",
+			expectedKeys: []string{"IsRaceOn", "Speed"},
+			expectedSize: 21,
+		},
+		{
+			testName:      "invalid padding",
+			format:        "S32 IsRaceOn\nPAD twelve",
+			expectedError: telemetry.ErrInvalidSchemaLine,
+		},
+		{
+			testName:      "unknown data type",
+			format:        "S32 IsRaceOn\nF16 Speed",
+			expectedError: telemetry.ErrUnknownDataType,
+		},
+		{
+			testName:      "duplicated field",
+			format:        "S32 IsRaceOn\nS32 IsRaceOn",
+			expectedError: telemetry.ErrDuplicateField,
+		},
+		{
+			testName:      "missing field name",
+			format:        "S32",
+			expectedError: telemetry.ErrInvalidSchemaLine,
+		},
+		{
+			testName:      "invalid field name",
+			format:        "F32 Speed-Kmh",
+			expectedError: telemetry.ErrInvalidSchemaLine,
+		},
+		{
+			testName:      "empty format",
+			format:        "\n# nothing here\n",
+			expectedError: telemetry.ErrEmptySchema,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.testName, func(t *testing.T) {
+			schema, err := telemetry.ParseSchema(strings.NewReader(tc.format))
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedKeys, schema.Keys)
+			assert.Equal(t, tc.expectedSize, schema.Size)
+		})
+	}
+}
+
+func TestSchema_Decode(t *testing.T) {
+	schema, err := telemetry.ParseSchema(strings.NewReader(
+		"S32 IsRaceOn\nU8 Gear\nS8 Steer\nU16 LapNumber\nF32 Speed\nS16 ForwardDirX\nF64 Distance",
+	))
+	require.NoError(t, err)
+
+	values := schema.Decode([]byte{
+		1, 0, 0, 0, 3, 0xff, 12, 0, 0, 0, 0x20, 0x41, 0xfe, 0xff, 0, 0, 0, 0, 0, 0x4a, 0x93, 0x40,
+	})
+
+	assert.Equal(t, map[string]float32{
+		"IsRaceOn":    1,
+		"Gear":        3,
+		"Steer":       -1,
+		"LapNumber":   12,
+		"Speed":       10,
+		"ForwardDirX": -2,
+		"Distance":    1234.5,
+	}, values)
+}
+
+func TestSchema_Check(t *testing.T) {
+	schema, err := telemetry.ParseSchema(strings.NewReader("S32 IsRaceOn\nU8 Gear\nF32 Speed"))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Check(make([]byte, 9)))
+	assert.NoError(t, schema.Check(make([]byte, 12)))
+	assert.ErrorIs(t, schema.Check(make([]byte, 8)), telemetry.ErrPacketTooShort)
+
+	// the fields past the end of the short packet are not set
+	assert.Equal(t, map[string]float32{"IsRaceOn": 1, "Gear": 3}, schema.Decode([]byte{1, 0, 0, 0, 3, 0}))
+}
+
+func TestSchema_Encode(t *testing.T) {
+	schema, err := telemetry.ParseSchema(strings.NewReader(
+		"S32 IsRaceOn\nU8 Gear\nS8 Steer\nU16 LapNumber\nF32 Speed\nS16 ForwardDirX\nF64 Distance",
+	))
+	require.NoError(t, err)
+
+	packet := []byte{1, 0, 0, 0, 3, 0xff, 12, 0, 0, 0, 0x20, 0x41, 0xfe, 0xff, 0, 0, 0, 0, 0, 0x4a, 0x93, 0x40}
+	assert.Equal(t, packet, schema.Encode(schema.Decode(packet)))
+
+	values := schema.Encode(map[string]float32{"Gear": 300, "Steer": -127.6, "LapNumber": -1})
+	assert.Len(t, values, schema.Size)
+	assert.Equal(t, map[string]float32{
+		"IsRaceOn":    0,
+		"Gear":        255,
+		"Steer":       -128,
+		"LapNumber":   0,
+		"Speed":       0,
+		"ForwardDirX": 0,
+		"Distance":    0,
+	}, schema.Decode(values))
+}
+
+func TestSchema_EncodeValues(t *testing.T) {
+	schema, err := telemetry.ParseSchema(strings.NewReader("U32 TimestampMS\nS16 ForwardDirX\nF32 Speed"))
+	require.NoError(t, err)
+
+	// 16777217 is not representable as float32
+	packet := schema.EncodeValues(map[string]telemetry.Value{
+		"TimestampMS": {DataType: "U32", Int: 16777217},
+		"ForwardDirX": {DataType: "S32", Int: -40000},
+		"Speed":       {DataType: "U8", Int: 42},
+	})
+	assert.Equal(t, map[string]telemetry.Value{
+		"TimestampMS": {DataType: "U32", Int: 16777217},
+		"ForwardDirX": {DataType: "S16", Int: -32768},
+		"Speed":       {DataType: "F32", Float: 42},
+	}, schema.DecodeValues(packet))
+	assert.Equal(t, packet, schema.EncodeValues(schema.DecodeValues(packet)))
+}
+
+func TestMergeKeys(t *testing.T) {
+	derived := []string{"IsRaceOn", "Gear"}
+	keys := telemetry.MergeKeys(derived, []string{"Speed", "Gear"}, []string{"IsRaceOn", "Speed", "RPM"})
+
+	assert.Equal(t, []string{"IsRaceOn", "Gear", "Speed", "RPM"}, keys)
+	assert.Equal(t, []string{"IsRaceOn", "Gear"}, derived, "the derived keys are not modified")
+}
@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"log"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// SessionTimeout is the time without packets after which the session of a source is forgotten
+const SessionTimeout = 10 * time.Minute
+
+// Sessions contains the decoder state of every source sending to the port, eg. the merged player car data,
+// so the packets of two consoles sending to the same port do not mix.
+type Sessions[T any] struct {
+	mu         sync.Mutex
+	newSession func() T
+	sessions   map[netip.AddrPort]*session[T]
+}
+
+type session[T any] struct {
+	state    T
+	lastSeen time.Time
+}
+
+// NewSessions creates a new Sessions, newSession creates the state of a new source
+func NewSessions[T any](newSession func() T) *Sessions[T] {
+	return &Sessions[T]{
+		newSession: newSession,
+		sessions:   map[netip.AddrPort]*session[T]{},
+	}
+}
+
+// Get returns the state of the source, a new state is created on the first packet of the source.
+// The sessions of the sources silent for longer than SessionTimeout are removed then.
+func (s *Sessions[T]) Get(source netip.AddrPort) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := s.sessions[source]; ok {
+		current.lastSeen = now
+		return current.state
+	}
+
+	for addr, expired := range s.sessions {
+		if now.Sub(expired.lastSeen) > SessionTimeout {
+			delete(s.sessions, addr)
+		}
+	}
+	log.Printf("New session of %s", source)
+
+	current := &session[T]{state: s.newSession(), lastSeen: now}
+	s.sessions[source] = current
+	return current.state
+}
+
+// Len returns the number of the sources
+func (s *Sessions[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sessions)
+}
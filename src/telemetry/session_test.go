@@ -0,0 +1,27 @@
+package telemetry_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions_Get(t *testing.T) {
+	created := 0
+	sessions := telemetry.NewSessions(func() map[string]float32 {
+		created++
+		return map[string]float32{}
+	})
+	first := netip.MustParseAddrPort("192.168.1.10:50001")
+	second := netip.MustParseAddrPort("192.168.1.11:50001")
+
+	sessions.Get(first)["LapNumber"] = 3
+	sessions.Get(second)["LapNumber"] = 1
+
+	assert.Equal(t, float32(3), sessions.Get(first)["LapNumber"])
+	assert.Equal(t, float32(1), sessions.Get(second)["LapNumber"])
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 2, sessions.Len())
+}
@@ -0,0 +1,108 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// Value is a decoded field in its native type. The integer types are kept in Int and the floats in Float,
+// so the 32 bit integers such as TimestampMS do not lose the precision of a float32.
+type Value struct {
+	DataType string
+	Int      int64
+	Float    float64
+}
+
+// DecodeValue reads a single field of the data type from the buffer
+func DecodeValue(dataType string, data []byte) Value {
+	value := Value{DataType: dataType}
+	switch dataType {
+	case "F32":
+		value.Float = float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	case "F64":
+		value.Float = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	case "U8":
+		value.Int = int64(data[0])
+	case "S8":
+		value.Int = int64(int8(data[0]))
+	case "U16":
+		value.Int = int64(binary.LittleEndian.Uint16(data))
+	case "S16":
+		value.Int = int64(int16(binary.LittleEndian.Uint16(data)))
+	case "S32":
+		value.Int = int64(int32(binary.LittleEndian.Uint32(data)))
+	default:
+		value.Int = int64(binary.LittleEndian.Uint32(data))
+	}
+	return value
+}
+
+// IsFloat reports whether the value is a floating point number
+func (v Value) IsFloat() bool {
+	return v.DataType == "F32" || v.DataType == "F64"
+}
+
+// Float32 returns the value as a float32, as stored in GameData.Data
+func (v Value) Float32() float32 {
+	if v.IsFloat() {
+		return float32(v.Float)
+	}
+	return float32(v.Int)
+}
+
+// Float64 returns the value as a float64, without the float32 rounding of the integers
+func (v Value) Float64() float64 {
+	if v.IsFloat() {
+		return v.Float
+	}
+	return float64(v.Int)
+}
+
+// Native returns the value as its Go type, eg. uint32 for U32, so database drivers store it as an integer
+func (v Value) Native() any {
+	switch v.DataType {
+	case "F32":
+		return float32(v.Float)
+	case "F64":
+		return v.Float
+	case "U8":
+		return uint8(v.Int)
+	case "S8":
+		return int8(v.Int)
+	case "U16":
+		return uint16(v.Int)
+	case "S16":
+		return int16(v.Int)
+	case "S32":
+		return int32(v.Int)
+	default:
+		return uint32(v.Int)
+	}
+}
+
+// String formats the integers without the fraction and the floats with the shortest exact representation
+func (v Value) String() string {
+	switch v.DataType {
+	case "F32":
+		return strconv.FormatFloat(v.Float, 'f', -1, 32)
+	case "F64":
+		return strconv.FormatFloat(v.Float, 'f', -1, 64)
+	}
+	return strconv.FormatInt(v.Int, 10)
+}
+
+// Floats converts the values to the float values stored in GameData.Data
+func Floats(values map[string]Value) map[string]float32 {
+	floats := make(map[string]float32, len(values))
+	FloatsInto(values, floats)
+	return floats
+}
+
+// FloatsInto clears the floats and converts the values into them, as Floats
+func FloatsInto(values map[string]Value, floats map[string]float32) {
+	clear(floats)
+	for key, value := range values {
+		floats[key] = value.Float32()
+	}
+}
@@ -0,0 +1,45 @@
+package telemetry_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_DecodeValues(t *testing.T) {
+	schema, err := telemetry.ParseSchema(strings.NewReader(
+		"S32 IsRaceOn\nU32 TimestampMS\nS32 CarOrdinal\nU8 Gear\nS8 Steer\nF32 Speed",
+	))
+	require.NoError(t, err)
+
+	packet := []byte{1, 0, 0, 0, 0x01, 0x02, 0x4a, 0x02, 0xfe, 0xff, 0xff, 0xff, 3, 0xff, 0, 0, 0x20, 0x41}
+	values := schema.DecodeValues(packet)
+
+	assert.Equal(t, telemetry.Value{DataType: "U32", Int: 38404609}, values["TimestampMS"])
+	assert.Equal(t, "38404609", values["TimestampMS"].String())
+	assert.Equal(t, uint32(38404609), values["TimestampMS"].Native())
+	assert.Equal(t, int32(-2), values["CarOrdinal"].Native())
+	assert.Equal(t, "-1", values["Steer"].String())
+	assert.Equal(t, uint8(3), values["Gear"].Native())
+	assert.Equal(t, "10", values["Speed"].String())
+	assert.Equal(t, float32(10), values["Speed"].Native())
+
+	floats := schema.Decode(packet)
+	assert.Equal(t, float32(-2), floats["CarOrdinal"], "S32 is signed")
+	assert.Equal(t, floats, telemetry.Floats(values))
+}
+
+func TestGameData_Format(t *testing.T) {
+	data := telemetry.GameData{
+		Data:   map[string]float32{"TimestampMS": 38404608, "Speed": 12.5, "IsRaceOn": 1},
+		Values: map[string]telemetry.Value{"TimestampMS": {DataType: "U32", Int: 38404609}},
+	}
+
+	assert.Equal(t, "38404609", data.Format("TimestampMS"))
+	assert.Equal(t, uint32(38404609), data.Native("TimestampMS"))
+	assert.Equal(t, "12.5", data.Format("Speed"))
+	assert.Equal(t, float32(1), data.Native("IsRaceOn"))
+}
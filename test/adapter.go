@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bluemanos/simracing-telemetry/src/telemetry"
+)
+
+// RecordingAdapter records the data published to it. The data is not released, so the samples are not reused.
+// When Block is set, every conversion waits until it is closed.
+type RecordingAdapter struct {
+	Block    chan struct{}
+	mu       sync.Mutex
+	received []telemetry.GameData
+}
+
+func (r *RecordingAdapter) ChannelInit(ctx context.Context, now time.Time, channel chan telemetry.GameData, port int) {
+	telemetry.Consume(ctx, channel, func(data telemetry.GameData) {
+		r.Convert(now, data, port)
+	})
+}
+
+func (r *RecordingAdapter) Convert(_ time.Time, data telemetry.GameData, _ int) {
+	if r.Block != nil {
+		<-r.Block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, data)
+}
+
+// Count returns the number of the recorded data
+func (r *RecordingAdapter) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.received)
+}
+
+// First returns the first recorded data
+func (r *RecordingAdapter) First() telemetry.GameData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.received[0]
+}
+
+// All returns a copy of the recorded data
+func (r *RecordingAdapter) All() []telemetry.GameData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]telemetry.GameData(nil), r.received...)
+}
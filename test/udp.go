@@ -0,0 +1,26 @@
+package test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FreePort returns a local UDP port which is not used
+func FreePort(t *testing.T) int {
+	t.Helper()
+	connection, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer connection.Close()
+	return connection.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Dial connects to the local UDP port, the connection is closed with the test
+func Dial(t *testing.T, port int) net.Conn {
+	t.Helper()
+	connection, err := net.Dial("udp", (&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}).String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = connection.Close() })
+	return connection
+}